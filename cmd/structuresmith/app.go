@@ -1,20 +1,46 @@
 package main
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"os"
+	"path"
 	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
 	"text/template"
+
+	"github.com/fatih/color"
 )
 
+// DefaultOutputDir is the output directory used if neither the command line nor the project specifies one.
+const DefaultOutputDir = "out"
+
 // Structuresmith holds configuration paths for the application.
 type Structuresmith struct {
 	ConfigFile   string
 	OutputDir    string
 	TemplatesDir string
+	PartialsDir  string
+	Force        bool
+	Strict       bool
+	Format       string
+	// Partials holds the shared template snippets loaded from the partials directory.
+	Partials Partials
+	// Overrides are values passed on the command line. They take precedence over all other values.
+	Overrides map[string]any
+
+	// redactor replaces the secrets resolved from the configuration in output.
+	redactor func(string) string
 }
 
 // Options represents the command line arguments passed to Structuresmith.
@@ -22,6 +48,19 @@ type Options struct {
 	ConfigFile   string
 	OutputDir    string
 	TemplatesDir string
+	PartialsDir  string
+	Force        bool
+	Strict       bool
+	Format       string
+	Overrides    map[string]any
+}
+
+// redact replaces the secrets resolved from the configuration in s.
+func (app *Structuresmith) redact(s string) string {
+	if app.redactor == nil {
+		return s
+	}
+	return app.redactor(s)
 }
 
 // newStructuresmith initializes a new instance of Structuresmith with provided options.
@@ -30,7 +69,145 @@ func newStructuresmith(opts Options) *Structuresmith {
 		ConfigFile:   opts.ConfigFile,
 		OutputDir:    opts.OutputDir,
 		TemplatesDir: opts.TemplatesDir,
+		PartialsDir:  opts.PartialsDir,
+		Force:        opts.Force,
+		Strict:       opts.Strict,
+		Overrides:    opts.Overrides,
+		Format:       opts.Format,
+	}
+}
+
+// projectFunc runs a command for a single project with a Structuresmith instance bound to its output directory.
+// It returns the human-readable and the machine-readable result of the command.
+type projectFunc func(app *Structuresmith, p Project) (fmt.Stringer, Report, error)
+
+// runProjects runs fn for every selected project and prints the results in the configured output format.
+// Failing projects do not stop the run; their errors are joined and returned after all projects ran.
+func (app *Structuresmith) runProjects(cfg ConfigFile, selectors []string, all bool, fn projectFunc) ([]Report, error) {
+	projects, err := cfg.SelectProjects(selectors, all)
+	if err != nil {
+		return nil, err
+	}
+
+	projectApps, err := app.forProjects(projects)
+	if err != nil {
+		return nil, err
+	}
+
+	textFormat := app.Format == "" || app.Format == FormatText
+	multiple := len(projects) > 1
+
+	var reports []Report
+	var errs []error
+	failed := make(map[string]error)
+	for i, p := range projects {
+		projectApp := projectApps[i]
+		if textFormat && multiple {
+			fmt.Printf("\n%s\n", color.New(color.Bold).Sprintf("==> %s (%s)", p.Name, projectApp.OutputDir))
+		}
+
+		result, report, err := fn(projectApp, p)
+		if err != nil {
+			log.Printf("Project %s failed: %s", p.Name, cfg.redact(err.Error()))
+			failed[p.Name] = err
+			errs = append(errs, fmt.Errorf("project %s: %w", p.Name, err))
+			continue
+		}
+		reports = append(reports, report.redact(cfg.redact))
+
+		if textFormat {
+			fmt.Printf("\n%s\n", cfg.redact(result.String()))
+		}
+	}
+
+	if !textFormat {
+		output, err := formatReports(reports, app.Format)
+		if err != nil {
+			return reports, err
+		}
+		fmt.Print(output)
+	} else if multiple {
+		fmt.Printf("%s\n", cfg.redact(projectsSummary(projects, reports, failed)))
+	}
+
+	return reports, errors.Join(errs...)
+}
+
+// projectsSummary returns a combined summary line for every project of a run.
+func projectsSummary(projects []Project, reports []Report, failed map[string]error) string {
+	summaries := make(map[string]string, len(reports))
+	for _, report := range reports {
+		summaries[report.Project] = report.Summary()
+	}
+
+	var result strings.Builder
+	result.WriteString(color.New(color.Bold).Sprint("Summary:") + "\n")
+	writer := tabwriter.NewWriter(&result, 0, 8, 2, ' ', 0)
+	for _, p := range projects {
+		summary := summaries[p.Name]
+		if err, exists := failed[p.Name]; exists {
+			summary = color.New(color.FgRed).Sprintf("error: %v", err)
+		}
+		_, _ = fmt.Fprintf(writer, "  %s\t%s\n", p.Name, summary)
+	}
+	if err := writer.Flush(); err != nil {
+		return "Error generating output"
+	}
+	return result.String()
+}
+
+// forProjects returns a Structuresmith instance for every project, bound to its own output directory.
+// It fails if two projects would share the same output directory and thereby the same lock file.
+func (app *Structuresmith) forProjects(projects []Project) ([]*Structuresmith, error) {
+	projectApps := make([]*Structuresmith, 0, len(projects))
+	outputDirs := make(map[string]string)
+	for _, p := range projects {
+		projectApp, err := app.forProject(p)
+		if err != nil {
+			return nil, err
+		}
+		if other, exists := outputDirs[projectApp.OutputDir]; exists {
+			return nil, fmt.Errorf("projects %s and %s share the output directory %s, use a pattern such as --output 'out/{{ .Project.Name }}'", other, p.Name, projectApp.OutputDir)
+		}
+		outputDirs[projectApp.OutputDir] = p.Name
+		projectApps = append(projectApps, projectApp)
+	}
+	return projectApps, nil
+}
+
+// forProject returns a copy of the Structuresmith instance that uses the output directory of the project.
+// An output directory passed on the command line takes precedence over the one declared by the project.
+// The output directory may be a template such as "repos/{{ .Project.Name }}".
+func (app *Structuresmith) forProject(p Project) (*Structuresmith, error) {
+	pattern := app.OutputDir
+	if pattern == "" {
+		pattern = p.Output
 	}
+	if pattern == "" {
+		pattern = DefaultOutputDir
+	}
+
+	outputDir, err := resolveOutputDir(pattern, p)
+	if err != nil {
+		return nil, err
+	}
+
+	projectApp := *app
+	projectApp.OutputDir = outputDir
+	return &projectApp, nil
+}
+
+// resolveOutputDir renders an output directory pattern for the given project.
+func resolveOutputDir(pattern string, p Project) (string, error) {
+	if !strings.Contains(pattern, "{{") {
+		return pattern, nil
+	}
+
+	rendered, err := renderTemplate("output", pattern, map[string]any{"Project": p}, renderOptions{})
+	if err != nil {
+		return "", fmt.Errorf("error rendering output directory %s: %w", pattern, err)
+	}
+	return filepath.Clean(string(rendered)), nil
 }
 
 // loadAndValidateConfig loads and validates the configuration file.
@@ -38,57 +215,114 @@ func (app *Structuresmith) loadAndValidateConfig() (ConfigFile, error) {
 	log.Println("Reading configuration...")
 	config, err := readConfig(app.ConfigFile, app.TemplatesDir)
 	if err != nil {
-		return ConfigFile{}, fmt.Errorf("error reading config: %v", err)
+		return ConfigFile{}, fmt.Errorf("error reading config: %w", err)
 	}
 
 	if err := config.validateConfig(); err != nil {
 		return ConfigFile{}, err
 	}
+	// Values passed on the command line may provide values required by group schemas
+	scopeOf := func(p Project) valueScope { return app.projectScope(p, config) }
+	if err := config.validateGroupValues(scopeOf, app.Strict); err != nil {
+		return ConfigFile{}, err
+	}
+
+	partials, err := loadPartials(app.partialsPath())
+	if err != nil {
+		return ConfigFile{}, err
+	}
+	app.Partials = partials
+	app.redactor = config.redact
 	return config, nil
 }
 
+// validateProjects processes every project that can be rendered, to report problems that only surface
+// once files are expanded, such as colliding destinations.
+func (app *Structuresmith) validateProjects(cfg ConfigFile) error {
+	var errs ValidationErrors
+	for i, projectCfg := range cfg.Projects {
+		if projectCfg.Abstract {
+			continue
+		}
+		p, err := cfg.FindProject(projectCfg.Name)
+		if err == nil {
+			_, err = app.processProject(p, cfg)
+		}
+		if err == nil {
+			continue
+		}
+
+		problems := []error{err}
+		if joined, ok := err.(interface{ Unwrap() []error }); ok {
+			problems = joined.Unwrap()
+		}
+		for _, problem := range problems {
+			errs = append(errs, cfg.errorAt(fmt.Sprintf("projects[%d]", i), "project %s: %s", projectCfg.Name, cfg.redact(problem.Error())))
+		}
+	}
+	return errs.err()
+}
+
 // diff generates a diff of the project file structures.
-func (app *Structuresmith) diff(project string, cfg ConfigFile) error {
-	projectConfig, err := cfg.FindProject(project)
+func (app *Structuresmith) diff(project string, cfg ConfigFile) (DiffResult, error) {
+	lock, err := LoadOrCreateLockFile(app.OutputDir)
 	if err != nil {
-		return err
+		return DiffResult{}, err
 	}
 
-	allFiles, err := app.processProject(projectConfig, cfg.TemplateGroups)
+	_, diffedFiles, err := app.diffProject(project, cfg, lock)
 	if err != nil {
-		return err
+		return DiffResult{}, err
 	}
 
-	lock, err := LoadOrCreateLockFile(app.OutputDir)
+	return app.applyContentLogic(diffedFiles)
+}
+
+// check renders the project in memory and compares it against the files on disk.
+// Unlike diff and render, it never writes to the output directory.
+func (app *Structuresmith) check(project string, cfg ConfigFile) (CheckResult, error) {
+	lock, err := LoadLockFileIfExists(app.OutputDir)
 	if err != nil {
-		return err
+		return CheckResult{}, err
 	}
 
-	diffedFiles := lock.Diff(allFiles)
-	diffedFiles = app.applySkipLogic(diffedFiles)
-	fmt.Printf("\n%s\n", diffedFiles)
-	return nil
+	_, diffedFiles, err := app.diffProject(project, cfg, lock)
+	if err != nil {
+		return CheckResult{}, err
+	}
+
+	return app.detectDrift(diffedFiles)
 }
 
-func (app *Structuresmith) render(project string, cfg ConfigFile) error {
+// diffProject processes the project and diffs its file structures against the lock file.
+func (app *Structuresmith) diffProject(project string, cfg ConfigFile, lock *AnvilLock) ([]FileStructure, DiffResult, error) {
 	p, err := cfg.FindProject(project)
 	if err != nil {
-		return err
+		return nil, DiffResult{}, err
 	}
 
-	allFiles, err := app.processProject(p, cfg.TemplateGroups)
+	allFiles, err := app.processProject(p, cfg)
 	if err != nil {
-		return err
+		return nil, DiffResult{}, err
 	}
 
+	diffedFiles := lock.Diff(allFiles)
+	diffedFiles = app.applySkipLogic(diffedFiles)
+	diffedFiles = app.applyChecksumLogic(diffedFiles, lock)
+	return allFiles, diffedFiles, nil
+}
+
+// render writes the project file structures to the output directory and returns the applied diff.
+func (app *Structuresmith) render(project string, cfg ConfigFile) (DiffResult, error) {
 	lock, err := LoadOrCreateLockFile(app.OutputDir)
 	if err != nil {
-		return err
+		return DiffResult{}, err
 	}
 
-	diffedFiles := lock.Diff(allFiles)
-	diffedFiles = app.applySkipLogic(diffedFiles)
-	fmt.Printf("\n%s\n", diffedFiles)
+	allFiles, diffedFiles, err := app.diffProject(project, cfg, lock)
+	if err != nil {
+		return DiffResult{}, err
+	}
 
 	// Build a set of skipped file destinations for quick lookup
 	skippedSet := make(map[string]struct{})
@@ -96,28 +330,73 @@ func (app *Structuresmith) render(project string, cfg ConfigFile) error {
 		skippedSet[file.Destination] = struct{}{}
 	}
 
-	for _, file := range allFiles {
+	// Build a set of locally modified file destinations for quick lookup
+	modifiedSet := make(map[string]struct{})
+	for _, file := range diffedFiles.ModifiedFiles {
+		modifiedSet[file.Destination] = struct{}{}
+	}
+
+	// Render all files in memory first, so that nothing is written if any of them fails
+	rendered := make(map[int][]byte)
+	var errs []error
+	for i, file := range allFiles {
+		fullPath := filepath.Join(app.OutputDir, file.Destination)
+
 		// Skip files that are marked as skipped (exist and have overwrite: false)
 		if _, shouldSkip := skippedSet[file.Destination]; shouldSkip {
-			log.Printf("Skipping %s (file exists and overwrite is disabled)", filepath.Join(app.OutputDir, file.Destination))
+			log.Printf("Skipping %s (file exists and overwrite is disabled)", app.redact(fullPath))
+			if allFiles[i].Checksum, err = computeFileChecksum(fullPath); err != nil {
+				return DiffResult{}, fmt.Errorf("computing checksum of %s: %w", fullPath, err)
+			}
 			continue
 		}
-		if err = app.renderFileStructure(file); err != nil {
-			return err
+
+		// Skip files that were edited by hand since the last render, unless forced.
+		// The previous checksum is kept so the file is still reported as modified.
+		if _, modified := modifiedSet[file.Destination]; modified && !app.Force {
+			log.Printf("Skipping %s (file has local modifications, use --force to overwrite)", app.redact(fullPath))
+			allFiles[i].Checksum = lock.findChecksum(file.Destination)
+			continue
 		}
+
+		content, err := app.renderContent(file)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		rendered[i] = content
+	}
+	if len(errs) > 0 {
+		return DiffResult{}, errors.Join(errs...)
+	}
+
+	for i, file := range allFiles {
+		content, ok := rendered[i]
+		if !ok {
+			continue
+		}
+		if err = app.writeRenderedFile(file, content); err != nil {
+			return DiffResult{}, err
+		}
+		allFiles[i].Checksum = computeChecksum(content)
 	}
 
 	err = app.deleteOrphanedFileStructures(diffedFiles)
 	if err != nil {
-		return err
+		return DiffResult{}, err
+	}
+
+	// Hand-edited files that are kept stay tracked, so they are still reported as modified
+	if !app.Force {
+		allFiles = append(allFiles, diffedFiles.ModifiedDeletedFiles...)
 	}
 
 	err = WriteLockFile(allFiles, app.OutputDir)
 	if err != nil {
-		return err
+		return DiffResult{}, err
 	}
 
-	return nil
+	return diffedFiles, nil
 }
 
 // applySkipLogic checks which files should be skipped based on overwrite setting
@@ -150,6 +429,125 @@ func (app *Structuresmith) applySkipLogic(diff DiffResult) DiffResult {
 	return result
 }
 
+// applyChecksumLogic detects files that were edited by hand since the last render.
+// It moves files from KeptFiles to ModifiedFiles and from DeletedFiles to ModifiedDeletedFiles
+// if their content on disk no longer matches the checksum recorded in the lock file.
+// Files without a recorded checksum or missing on disk are left untouched.
+func (app *Structuresmith) applyChecksumLogic(diff DiffResult, lock *AnvilLock) DiffResult {
+	result := DiffResult{
+		NewFiles:             diff.NewFiles,
+		SkippedFiles:         diff.SkippedFiles,
+		ModifiedFiles:        diff.ModifiedFiles,
+		ModifiedDeletedFiles: diff.ModifiedDeletedFiles,
+	}
+
+	for _, file := range diff.KeptFiles {
+		if app.isModifiedOnDisk(file.Destination, lock.findChecksum(file.Destination)) {
+			result.ModifiedFiles = append(result.ModifiedFiles, file)
+		} else {
+			result.KeptFiles = append(result.KeptFiles, file)
+		}
+	}
+
+	// Deleted files have no rendered content, so the recorded checksum is kept
+	for _, file := range diff.DeletedFiles {
+		file.Checksum = lock.findChecksum(file.Destination)
+		if app.isModifiedOnDisk(file.Destination, file.Checksum) {
+			result.ModifiedDeletedFiles = append(result.ModifiedDeletedFiles, file)
+		} else {
+			result.DeletedFiles = append(result.DeletedFiles, file)
+		}
+	}
+
+	return result
+}
+
+// applyContentLogic renders the files of a DiffResult in memory and compares them with the files on disk.
+// It moves files from KeptFiles to UnchangedFiles if their content is byte-identical and records
+// unified diffs for files that would be overwritten or were modified by hand.
+func (app *Structuresmith) applyContentLogic(diff DiffResult) (DiffResult, error) {
+	result := DiffResult{
+		DeletedFiles:         diff.DeletedFiles,
+		SkippedFiles:         diff.SkippedFiles,
+		UnchangedFiles:       diff.UnchangedFiles,
+		ModifiedDeletedFiles: diff.ModifiedDeletedFiles,
+		Patches:              make(map[string]string),
+	}
+	var errs []error
+
+	for _, file := range diff.NewFiles {
+		content, err := app.renderContent(file)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		file.Checksum = computeChecksum(content)
+		result.NewFiles = append(result.NewFiles, file)
+	}
+
+	for _, file := range diff.KeptFiles {
+		patch, err := app.patchAgainstDisk(&file)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if patch == "" && app.fileExistsOnDisk(file.Destination) {
+			result.UnchangedFiles = append(result.UnchangedFiles, file)
+			continue
+		}
+		if patch != "" {
+			result.Patches[file.Destination] = patch
+		}
+		result.KeptFiles = append(result.KeptFiles, file)
+	}
+
+	for _, file := range diff.ModifiedFiles {
+		patch, err := app.patchAgainstDisk(&file)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if patch != "" {
+			result.Patches[file.Destination] = patch
+		}
+		result.ModifiedFiles = append(result.ModifiedFiles, file)
+	}
+
+	if len(errs) > 0 {
+		return DiffResult{}, errors.Join(errs...)
+	}
+	return result, nil
+}
+
+// patchAgainstDisk renders a file in memory, records its checksum and returns the unified diff
+// between the file on disk and the rendered content. Missing files are diffed against empty content.
+func (app *Structuresmith) patchAgainstDisk(file *FileStructure) (string, error) {
+	rendered, err := app.renderContent(*file)
+	if err != nil {
+		return "", err
+	}
+	file.Checksum = computeChecksum(rendered)
+
+	actual, err := os.ReadFile(filepath.Join(app.OutputDir, file.Destination))
+	if err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("reading %s: %w", file.Destination, err)
+	}
+
+	return unifiedDiff("a/"+file.Destination, "b/"+file.Destination, actual, rendered), nil
+}
+
+// isModifiedOnDisk reports whether the file in the output directory differs from the given checksum.
+func (app *Structuresmith) isModifiedOnDisk(destination, checksum string) bool {
+	if checksum == "" {
+		return false
+	}
+	actual, err := computeFileChecksum(filepath.Join(app.OutputDir, destination))
+	if err != nil {
+		return false
+	}
+	return actual != checksum
+}
+
 // shouldOverwrite returns true if the file should be overwritten.
 // Defaults to true if Overwrite is not specified (nil).
 func shouldOverwrite(file FileStructure) bool {
@@ -166,12 +564,60 @@ func (app *Structuresmith) fileExistsOnDisk(destination string) bool {
 	return err == nil
 }
 
-// renderFileStructure creates a file based on the FileStructure details.
-func (app *Structuresmith) renderFileStructure(file FileStructure) error {
+// renderContent renders the content of a FileStructure in memory.
+// Files that are not templated are returned as is.
+func (app *Structuresmith) renderContent(file FileStructure) ([]byte, error) {
+	var name, content string
+
+	// Handle different file sources
+	switch {
+	case file.Content != "":
+		name, content = file.Destination, file.Content
+	case file.SourceURL != "":
+		downloaded, err := downloadFileContent(file.SourceURL)
+		if err != nil {
+			return nil, fmt.Errorf("downloading file from URL: %w", err)
+		}
+		name, content = file.SourceURL, downloaded
+	case file.Source != "":
+		data, err := os.ReadFile(file.Source)
+		if err != nil {
+			return nil, fmt.Errorf("reading source file: %w", err)
+		}
+		name, content = file.Source, string(data)
+	default:
+		return nil, fmt.Errorf("file structure lacks source information")
+	}
+
+	if !shouldTemplate(file, []byte(content)) {
+		return []byte(content), nil
+	}
+	return executeTemplate(name, content, file.Values, renderOptions{Partials: app.Partials, Strict: app.Strict, Delims: file.Delims, Redact: app.redact})
+}
+
+// binarySniffLen is the number of leading bytes inspected to detect binary content.
+const binarySniffLen = 8000
+
+// shouldTemplate returns true if the content of the file should be rendered as a template.
+// Unless specified explicitly, binary content is copied as is.
+func shouldTemplate(file FileStructure, content []byte) bool {
+	if file.Template != nil {
+		return *file.Template
+	}
+	return !isBinary(content)
+}
+
+// isBinary reports whether content looks like binary data, i.e. contains a NUL byte near its start.
+func isBinary(content []byte) bool {
+	return bytes.IndexByte(content[:min(len(content), binarySniffLen)], 0) != -1
+}
+
+// writeRenderedFile writes rendered content to the destination of a FileStructure.
+func (app *Structuresmith) writeRenderedFile(file FileStructure, content []byte) error {
 	outputPath := filepath.Join(app.OutputDir, filepath.Dir(file.Destination))
 	fullPath := filepath.Join(outputPath, filepath.Base(file.Destination))
 
-	log.Printf("Processing %s", fullPath)
+	log.Printf("Processing %s", app.redact(fullPath))
 	if err := os.MkdirAll(outputPath, os.ModePerm); err != nil {
 		return fmt.Errorf("creating directory: %w", err)
 	}
@@ -182,43 +628,43 @@ func (app *Structuresmith) renderFileStructure(file FileStructure) error {
 		perm = *file.Permissions
 	}
 
-	// Handle different file sources
-	switch {
-	case file.Content != "":
-		return handleFileCreation(fullPath, file.Content, file.Values, perm)
-	case file.SourceURL != "":
-		content, err := downloadFileContent(file.SourceURL)
-		if err != nil {
-			return fmt.Errorf("downloading file from URL: %w", err)
-		}
-		return handleFileCreation(fullPath, content, file.Values, perm)
-	case file.Source != "":
-		content, err := os.ReadFile(file.Source)
-		if err != nil {
-			return fmt.Errorf("reading source file: %w", err)
-		}
-		return handleFileCreation(fullPath, string(content), file.Values, perm)
-	default:
-		return fmt.Errorf("file structure lacks source information")
-	}
+	return copyContentToFile(content, fullPath, perm)
 }
 
-// handleFileCreation creates or copies a file based on provided content or source.
-func handleFileCreation(fullPath, content string, values map[string]any, perm FileMode) error {
-	// Attempt to create a templated file
-	err := createTemplatedFile(fullPath, content, values, perm)
+// executeTemplate renders content as a template with the provided values.
+// If templating fails, the content is used as is, unless strict mode is enabled.
+func executeTemplate(name, content string, values map[string]any, opts renderOptions) ([]byte, error) {
+	// Attempt to render the template
+	rendered, err := renderTemplate(name, content, values, opts)
 	if err != nil {
-		// If templating fails, copy the content directly
-		return copyContentToFile(content, fullPath, perm)
+		if opts.Strict {
+			return nil, err
+		}
+		redact := opts.Redact
+		if redact == nil {
+			redact = func(s string) string { return s }
+		}
+		log.Printf("Warning: copying %s without templating: %s", redact(name), redact(err.Error()))
+		return []byte(content), nil
 	}
-	return nil
+	return rendered, nil
 }
 
 // deleteOrphanedFileStructures removes any files that are no longer needed.
+// Files that were edited by hand since the last render are only deleted if forced.
 func (app *Structuresmith) deleteOrphanedFileStructures(diffResult DiffResult) error {
-	for _, file := range diffResult.DeletedFiles {
+	deletedFiles := diffResult.DeletedFiles
+	for _, file := range diffResult.ModifiedDeletedFiles {
+		if !app.Force {
+			log.Printf("Skipping deletion of %s (file has local modifications, use --force to delete)", app.redact(filepath.Join(app.OutputDir, file.Destination)))
+			continue
+		}
+		deletedFiles = append(slices.Clip(deletedFiles), file)
+	}
+
+	for _, file := range deletedFiles {
 		fullPath := filepath.Join(app.OutputDir, file.Destination)
-		log.Printf("Deleting %s", fullPath)
+		log.Printf("Deleting %s", app.redact(fullPath))
 
 		if err := os.Remove(fullPath); err != nil && !os.IsNotExist(err) {
 			return fmt.Errorf("error removing file %s: %w", fullPath, err)
@@ -251,62 +697,356 @@ func (app *Structuresmith) removeEmptyDirs(dir string) error {
 	return nil
 }
 
-func (app *Structuresmith) processProject(p Project, globalGroups map[string][]FileStructure) ([]FileStructure, error) {
+// processProject resolves the files of a project and its template groups with their effective values.
+func (app *Structuresmith) processProject(p Project, cfg ConfigFile) ([]FileStructure, error) {
 	var allFiles []FileStructure
+	scope := app.projectScope(p, cfg)
 
 	// Process individual files
 	for _, file := range p.Files {
-		files, err := app.processFileStructure(file)
+		file.origin = "project " + p.Name
+		file.Values = mergeValues(scope.Globals, scope.Project, file.Values, scope.Overrides, scope.Context)
+		file.Delims = firstDelims(file.Delims, scope.Delims)
+		files, err := app.processFile(file)
 		if err != nil {
-			return nil, fmt.Errorf("error processing file structure: %w", err)
+			return nil, err
 		}
 		allFiles = append(allFiles, files...)
 	}
 
 	// Process groups of files
 	for _, groupRef := range p.Groups {
-		group, exists := globalGroups[groupRef.GroupName]
-		if !exists {
-			return nil, fmt.Errorf("template group %s not found in configuration", groupRef.GroupName)
+		values := scope.groupValues(scope.Schemas[groupRef.GroupName].Defaults(), nil, groupRef.Values)
+		included, err := evaluateWhen(groupRef.When, values, firstDelims(groupRef.Delims, scope.Delims), app.Strict)
+		if err != nil {
+			return nil, fmt.Errorf("error evaluating when of group %s: %w", groupRef.GroupName, err)
+		}
+		if !included {
+			continue
+		}
+		files, err := app.processGroup(groupRef, nil, scope, cfg.TemplateGroups, nil)
+		if err != nil {
+			return nil, err
+		}
+		allFiles = append(allFiles, files...)
+	}
+
+	// Expose the built-in template context to every file and render templated destinations
+	for i := range allFiles {
+		allFiles[i].Values = app.withSmithContext(p, allFiles[i])
+
+		destination, err := renderDestination(allFiles[i])
+		if err != nil {
+			return nil, err
 		}
+		if destination != allFiles[i].Destination {
+			allFiles[i].Destination = destination
+			allFiles[i].Values = app.withSmithContext(p, allFiles[i])
+		}
+	}
+
+	return resolveDestinationCollisions(allFiles)
+}
+
+// evaluateWhen renders a when expression with the given values and reports whether the file is included.
+// An empty expression always includes the file. Missing values exclude it, unless strict mode is enabled.
+func evaluateWhen(expr string, values map[string]any, delims []string, strict bool) (bool, error) {
+	if expr == "" {
+		return true, nil
+	}
+
+	rendered, err := renderTemplate("when", expr, values, renderOptions{Strict: strict, Delims: delims})
+	if err != nil {
+		return false, err
+	}
+
+	switch strings.ToLower(strings.TrimSpace(string(rendered))) {
+	case "", "false", "0", "no", "off", "<no value>":
+		return false, nil
+	default:
+		return true, nil
+	}
+}
+
+// renderDestination renders a templated destination with the values of the file and checks
+// that the result stays inside of the output directory.
+func renderDestination(file FileStructure) (string, error) {
+	destination := file.Destination
+
+	if left, _ := delimsOrDefault(file.Delims); strings.Contains(destination, left) {
+		// Destinations are always rendered strictly, as a half-rendered path is never intended
+		rendered, err := renderTemplate(destination, destination, file.Values, renderOptions{Strict: true, Delims: file.Delims})
+		if err != nil {
+			return "", fmt.Errorf("error rendering destination %s: %w", file.Destination, err)
+		}
+		destination = string(rendered)
+	}
+
+	if cleaned := filepath.Clean(destination); cleaned == "." || !filepath.IsLocal(cleaned) {
+		return "", fmt.Errorf("destination %q of %s resolves outside of the output directory", destination, file.Destination)
+	}
+	return destination, nil
+}
+
+// pathPlaceholderPattern matches placeholders such as __packageName__ in file and directory names.
+var pathPlaceholderPattern = regexp.MustCompile(`__([A-Za-z][A-Za-z0-9]*)__`)
+
+// replacePathPlaceholders replaces placeholders such as __packageName__ in a path with the value of the same name.
+// Placeholders without a matching value, such as __init__, are kept as is.
+func replacePathPlaceholders(relPath string, values map[string]any) string {
+	return pathPlaceholderPattern.ReplaceAllStringFunc(relPath, func(placeholder string) string {
+		value, exists := values[pathPlaceholderPattern.FindStringSubmatch(placeholder)[1]]
+		if !exists || value == nil {
+			return placeholder
+		}
+		if _, isMap := value.(map[string]any); isMap {
+			return placeholder
+		}
+		return fmt.Sprint(value)
+	})
+}
+
+// valueScope holds the values, template delimiters and group schemas shared by all files of a project.
+type valueScope struct {
+	Globals   map[string]any
+	Project   map[string]any
+	Overrides map[string]any
+	Delims    []string
+	Schemas   map[string]ValuesSchema
+	// Context holds the built-in template context known before files are expanded,
+	// so that when and forEach expressions can use it.
+	Context map[string]any
+}
+
+// projectScope returns the value scope shared by all files of a project.
+func (app *Structuresmith) projectScope(p Project, cfg ConfigFile) valueScope {
+	return valueScope{
+		Globals:   cfg.Globals,
+		Project:   p.Values,
+		Overrides: app.Overrides,
+		Delims:    p.Delims,
+		Schemas:   cfg.GroupSchemas,
+		Context:   map[string]any{SmithValuesKey: app.smithContext(p)},
+	}
+}
+
+// groupValues merges the values of a file in a template group in the order:
+// schema defaults < globals < file defaults < project values < group reference values < overrides.
+// Both processing and validating template groups merge values through it, so that they never disagree.
+func (s valueScope) groupValues(schemaDefaults, fileDefaults, refValues map[string]any) map[string]any {
+	return mergeValues(schemaDefaults, s.Globals, fileDefaults, s.Project, refValues, s.Overrides, s.Context)
+}
+
+// delimsOrDefault returns the left and right template delimiters, or the default "{{" and "}}" if none are set.
+func delimsOrDefault(delims []string) (left, right string) {
+	if len(delims) == 2 {
+		return delims[0], delims[1]
+	}
+	return "{{", "}}"
+}
+
+// firstDelims returns the first non-empty template delimiters, in order of precedence.
+func firstDelims(candidates ...[]string) []string {
+	for _, delims := range candidates {
+		if len(delims) > 0 {
+			return delims
+		}
+	}
+	return nil
+}
 
-		for _, file := range group {
-			mergedValues := mergeValues(file.Values, groupRef.Values)
-			file.Values = mergedValues
-			files, err := app.processFileStructure(file)
+// processGroup processes the files of a template group referenced by a project.
+// Entries referencing other groups are resolved recursively, passing their values down
+// as defaults for the included group. The chain holds the groups currently being resolved.
+// Values are merged as described by valueScope.groupValues.
+func (app *Structuresmith) processGroup(groupRef TemplateGroupRef, defaults map[string]any, scope valueScope, globalGroups map[string][]FileStructure, chain []string) ([]FileStructure, error) {
+	groupName := groupRef.GroupName
+	if slices.Contains(chain, groupName) {
+		return nil, fmt.Errorf("template group cycle detected: %s", strings.Join(append(chain, groupName), " -> "))
+	}
+
+	group, exists := globalGroups[groupName]
+	if !exists {
+		return nil, fmt.Errorf("template group %s not found in configuration", groupName)
+	}
+	chain = append(slices.Clone(chain), groupName)
+
+	var allFiles []FileStructure
+	for _, file := range group {
+		fileDefaults := mergeValues(file.Values, defaults)
+
+		values := scope.groupValues(scope.Schemas[groupName].Defaults(), fileDefaults, groupRef.Values)
+		delims := firstDelims(file.Delims, groupRef.Delims, scope.Delims)
+
+		if file.GroupName != "" {
+			included, err := evaluateWhen(file.When, values, delims, app.Strict)
+			if err != nil {
+				return nil, fmt.Errorf("error evaluating when of group %s in template group %s: %w", file.GroupName, groupName, err)
+			}
+			if !included {
+				continue
+			}
+
+			includedRef := TemplateGroupRef{
+				GroupName: file.GroupName,
+				Values:    groupRef.Values,
+				Delims:    firstDelims(file.Delims, groupRef.Delims),
+			}
+			files, err := app.processGroup(includedRef, fileDefaults, scope, globalGroups, chain)
 			if err != nil {
-				return nil, fmt.Errorf("error processing file structure: %w", err)
+				return nil, err
 			}
 			allFiles = append(allFiles, files...)
+			continue
+		}
+
+		file.origin = "template group " + groupName
+		file.Values = values
+		file.Delims = delims
+		files, err := app.processFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("template group %s: %w", groupName, err)
 		}
+		allFiles = append(allFiles, files...)
 	}
 
 	return allFiles, nil
 }
 
-// mergeValues merges group-level values with file-level.
-// For slices and non-map values, the value from src will overwrite the one in dst.
-func mergeValues(dst, src map[string]any) map[string]any {
-	merged := make(map[string]any)
+// processFile expands a file with resolved values into one file per item of its forEach expression,
+// drops the files excluded by their when expression and processes the remaining ones.
+func (app *Structuresmith) processFile(file FileStructure) ([]FileStructure, error) {
+	expanded, err := expandForEach(file, app.Strict)
+	if err != nil {
+		return nil, fmt.Errorf("error evaluating forEach of %s: %w", file.Destination, err)
+	}
+
+	var allFiles []FileStructure
+	for _, file := range expanded {
+		included, err := evaluateWhen(file.When, file.Values, file.Delims, app.Strict)
+		if err != nil {
+			return nil, fmt.Errorf("error evaluating when of %s: %w", file.Destination, err)
+		}
+		if !included {
+			continue
+		}
+
+		files, err := app.processFileStructure(file)
+		if err != nil {
+			return nil, fmt.Errorf("error processing file structure: %w", err)
+		}
+		allFiles = append(allFiles, files...)
+	}
+	return allFiles, nil
+}
+
+// Values exposed to the files generated by a forEach expression.
+const (
+	forEachItemKey  = "item"
+	forEachIndexKey = "index"
+)
+
+// expandForEach returns one copy of the file per item of its forEach expression, with the item and
+// its index exposed as .item and .index. For dictionaries, the index is the key and items are ordered by key.
+// Files without a forEach expression are returned as is.
+func expandForEach(file FileStructure, strict bool) ([]FileStructure, error) {
+	if file.ForEach == "" {
+		return []FileStructure{file}, nil
+	}
+
+	indexes, items, err := evaluateForEach(file.ForEach, file.Values, file.Delims, strict)
+	if err != nil {
+		return nil, err
+	}
+
+	left, _ := delimsOrDefault(file.Delims)
+	if len(items) > 1 && !strings.Contains(file.Destination, left) {
+		return nil, fmt.Errorf("destination must be a template to generate distinct files, e.g. \"deploy/{{ .item }}.yaml\"")
+	}
 
-	// Copy values from dst into the new map
-	for key, val := range dst {
-		merged[key] = val
+	files := make([]FileStructure, 0, len(items))
+	for i, item := range items {
+		expanded := file
+		expanded.ForEach = ""
+		expanded.Values = mergeValues(file.Values, map[string]any{forEachItemKey: item, forEachIndexKey: indexes[i]})
+		files = append(files, expanded)
+	}
+	return files, nil
+}
+
+// evaluateForEach evaluates a forEach expression such as ".environments" against the values
+// and returns the indexes and items of the resulting list or dictionary.
+// A missing value yields no items, unless strict mode is enabled.
+func evaluateForEach(expr string, values map[string]any, delims []string, strict bool) ([]any, []any, error) {
+	left, right := delimsOrDefault(delims)
+	expr = strings.TrimSpace(expr)
+	if strings.HasPrefix(expr, left) && strings.HasSuffix(expr, right) {
+		expr = strings.TrimSpace(expr[len(left) : len(expr)-len(right)])
+	}
+
+	// Capture the value of the expression instead of its string representation
+	var result any
+	tmpl, err := newTemplate("forEach", renderOptions{Strict: strict})
+	if err != nil {
+		return nil, nil, err
+	}
+	tmpl.Funcs(template.FuncMap{"capture": func(v any) string {
+		result = v
+		return ""
+	}})
+	tmpl.Delims(left, right)
+	if _, err := tmpl.Parse(left + " capture (" + expr + ") " + right); err != nil {
+		return nil, nil, newTemplateError("forEach", err)
+	}
+	if err := tmpl.Execute(io.Discard, values); err != nil {
+		return nil, nil, newTemplateError("forEach", err)
 	}
 
-	// Merge values from src into the new map
-	for key, srcVal := range src {
-		if dstVal, exists := merged[key]; exists {
-			// If both values are maps, merge them recursively
-			if srcMap, srcOk := srcVal.(map[string]any); srcOk {
-				if dstMap, dstOk := dstVal.(map[string]any); dstOk {
-					merged[key] = mergeValues(dstMap, srcMap)
-					continue
+	if result == nil {
+		return nil, nil, nil
+	}
+	rv := reflect.ValueOf(result)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		indexes, items := make([]any, rv.Len()), make([]any, rv.Len())
+		for i := range items {
+			indexes[i], items[i] = i, rv.Index(i).Interface()
+		}
+		return indexes, items, nil
+	case reflect.Map:
+		keys := rv.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+		})
+		indexes, items := make([]any, len(keys)), make([]any, len(keys))
+		for i, key := range keys {
+			indexes[i], items[i] = key.Interface(), rv.MapIndex(key).Interface()
+		}
+		return indexes, items, nil
+	default:
+		return nil, nil, fmt.Errorf("%s must evaluate to a list or dictionary, got %T", expr, result)
+	}
+}
+
+// mergeValues deep-merges layers of values, where later layers take precedence over earlier ones.
+// Nested maps are merged recursively. For slices and non-map values, the later value overwrites the earlier one.
+func mergeValues(layers ...map[string]any) map[string]any {
+	merged := make(map[string]any)
+
+	for _, layer := range layers {
+		for key, srcVal := range layer {
+			if dstVal, exists := merged[key]; exists {
+				// If both values are maps, merge them recursively
+				if srcMap, srcOk := srcVal.(map[string]any); srcOk {
+					if dstMap, dstOk := dstVal.(map[string]any); dstOk {
+						merged[key] = mergeValues(dstMap, srcMap)
+						continue
+					}
 				}
 			}
+			// For all other cases, or if the key doesn't exist in merged, set/overwrite the merged value
+			merged[key] = srcVal
 		}
-		// For all other cases, or if the key doesn't exist in merged, set/overwrite the merged value
-		merged[key] = srcVal
 	}
 	return merged
 }
@@ -329,7 +1069,7 @@ func (app *Structuresmith) processFileStructure(file FileStructure) ([]FileStruc
 		return []FileStructure{file}, nil
 	}
 
-	return nil, fmt.Errorf("neither source, sourceUrl nor content defined in file structure: %v", file)
+	return nil, fmt.Errorf("neither source, sourceUrl nor content defined for %s", file.Destination)
 }
 
 // processDirectory processes each file within a directory.
@@ -344,19 +1084,45 @@ func (app *Structuresmith) processDirectory(directory FileStructure) ([]FileStru
 			if err != nil {
 				return fmt.Errorf("error getting relative path: %w", err)
 			}
-			allFiles = append(allFiles, FileStructure{
+			file := FileStructure{
 				Source:      path,
-				Destination: filepath.Join(directory.Destination, relPath),
+				Destination: filepath.Join(directory.Destination, replacePathPlaceholders(relPath, directory.Values)),
 				Values:      directory.Values,
 				Permissions: directory.Permissions,
 				Overwrite:   directory.Overwrite,
-			})
+				Template:    directory.Template,
+				Delims:      directory.Delims,
+				Priority:    directory.Priority,
+				Override:    directory.Override,
+				origin:      directory.origin,
+			}
+			if matchesRawPattern(directory.Raw, relPath) {
+				raw := false
+				file.Template = &raw
+			}
+			allFiles = append(allFiles, file)
 		}
 		return nil
 	})
 	return allFiles, err
 }
 
+// matchesRawPattern reports whether a path relative to a directory source matches any of the raw patterns.
+// Patterns containing a slash are matched against the relative path, others against the file name.
+func matchesRawPattern(patterns []string, relPath string) bool {
+	relPath = filepath.ToSlash(relPath)
+	for _, pattern := range patterns {
+		name := path.Base(relPath)
+		if strings.Contains(pattern, "/") {
+			name = relPath
+		}
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
 // downloadFileContent fetches content from a URL and returns it as a string.
 func downloadFileContent(fileURL string) (string, error) {
 	resp, err := http.Get(fileURL)
@@ -381,28 +1147,78 @@ func downloadFileContent(fileURL string) (string, error) {
 	return string(body), nil
 }
 
-// createTemplatedFile creates a file from a template content and values.
-func createTemplatedFile(path, content string, values map[string]any, perm FileMode) error {
-	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm.Mode())
+// renderOptions controls how templates are rendered.
+type renderOptions struct {
+	// Partials are loaded into every template.
+	Partials Partials
+	// Strict fails on missing keys and template errors instead of copying the content as is.
+	Strict bool
+	// Delims are the left and right delimiters of the template. Partials always use the default delimiters.
+	Delims []string
+	// Redact replaces secrets in warnings that are logged instead of failing.
+	Redact func(string) string
+}
+
+// renderTemplate renders template content with values and partials into memory.
+func renderTemplate(name, content string, values map[string]any, opts renderOptions) ([]byte, error) {
+	tmpl, err := newTemplate(name, opts)
 	if err != nil {
-		return fmt.Errorf("creating file: %w", err)
+		return nil, err
 	}
-	defer func() {
-		if cerr := f.Close(); cerr != nil {
-			fmt.Printf("error closing file: %v\n", cerr)
-		}
-	}()
 
-	tmpl, err := template.New(filepath.Base(path)).Parse(content)
+	tmpl, err = tmpl.Delims(delimsOrDefault(opts.Delims)).Parse(content)
 	if err != nil {
-		return fmt.Errorf("parsing template: %w", err)
+		return nil, newTemplateError(name, err)
 	}
 
-	if err := tmpl.Execute(f, values); err != nil {
-		return err // Return the error to indicate templating failure
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, values); err != nil {
+		return nil, newTemplateError(name, err) // Return the error to indicate templating failure
 	}
 
-	return nil
+	return buf.Bytes(), nil
+}
+
+// TemplateError describes a template error with its position in the template.
+type TemplateError struct {
+	Template string
+	Line     int
+	Column   int
+	Message  string
+}
+
+// Error returns the error in the form "template:line:column: message".
+func (e *TemplateError) Error() string {
+	switch {
+	case e.Line > 0 && e.Column > 0:
+		return fmt.Sprintf("%s:%d:%d: %s", e.Template, e.Line, e.Column, e.Message)
+	case e.Line > 0:
+		return fmt.Sprintf("%s:%d: %s", e.Template, e.Line, e.Message)
+	default:
+		return fmt.Sprintf("%s: %s", e.Template, e.Message)
+	}
+}
+
+// templateErrorPattern extracts the position from errors of text/template, e.g.
+// `template: README.md:3:7: executing "README.md" at <.Autor>: map has no entry for key "Autor"`.
+var templateErrorPattern = regexp.MustCompile(`(?s)^template: (.+?):(\d+)(?::(\d+))?: (?:executing ".*?" )?(.*)$`)
+
+// newTemplateError converts an error of text/template into a TemplateError.
+// Errors raised in partials are reported with the position in the partial.
+func newTemplateError(name string, err error) error {
+	var templateErr *TemplateError
+	if errors.As(err, &templateErr) {
+		return err
+	}
+
+	match := templateErrorPattern.FindStringSubmatch(err.Error())
+	if match == nil {
+		return &TemplateError{Template: name, Message: err.Error()}
+	}
+
+	line, _ := strconv.Atoi(match[2])
+	column, _ := strconv.Atoi(match[3])
+	return &TemplateError{Template: match[1], Line: line, Column: column, Message: match[4]}
 }
 
 // copyFile copies a file from source to destination.
@@ -419,7 +1235,7 @@ func copyFile(src, dst string, perm FileMode) error {
 	return nil
 }
 
-// copyContentToFile writes string content directly to a file.
-func copyContentToFile(content, filePath string, perm FileMode) error {
-	return os.WriteFile(filePath, []byte(content), perm.Mode())
+// copyContentToFile writes content directly to a file.
+func copyContentToFile(content []byte, filePath string, perm FileMode) error {
+	return os.WriteFile(filePath, content, perm.Mode())
 }
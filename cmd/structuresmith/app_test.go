@@ -1,8 +1,11 @@
 package main
 
 import (
+	"bytes"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -74,9 +77,12 @@ func TestRenderFileStructureWithPermissions(t *testing.T) {
 				os.RemoveAll(filepath.Join(tmpDir, entry.Name()))
 			}
 
-			err := app.renderFileStructure(tt.file)
+			content, err := app.renderContent(tt.file)
 			if err != nil {
-				t.Fatalf("renderFileStructure() error = %v", err)
+				t.Fatalf("renderContent() error = %v", err)
+			}
+			if err := app.writeRenderedFile(tt.file, content); err != nil {
+				t.Fatalf("writeRenderedFile() error = %v", err)
 			}
 
 			fullPath := filepath.Join(tmpDir, tt.file.Destination)
@@ -114,21 +120,24 @@ func TestRenderFileStructureWithTemplateAndPermissions(t *testing.T) {
 		Permissions: &perm,
 	}
 
-	err = app.renderFileStructure(file)
+	content, err := app.renderContent(file)
 	if err != nil {
-		t.Fatalf("renderFileStructure() error = %v", err)
+		t.Fatalf("renderContent() error = %v", err)
+	}
+	if err := app.writeRenderedFile(file, content); err != nil {
+		t.Fatalf("writeRenderedFile() error = %v", err)
 	}
 
 	fullPath := filepath.Join(tmpDir, file.Destination)
 
 	// Check content was templated correctly
-	content, err := os.ReadFile(fullPath)
+	written, err := os.ReadFile(fullPath)
 	if err != nil {
 		t.Fatalf("Failed to read file: %v", err)
 	}
 	expected := "#!/bin/bash\necho 'Hello World'"
-	if string(content) != expected {
-		t.Errorf("File content = %q, want %q", string(content), expected)
+	if string(written) != expected {
+		t.Errorf("File content = %q, want %q", string(written), expected)
 	}
 
 	// Check permissions
@@ -479,3 +488,923 @@ func TestProcessDirectoryPreservesOverwrite(t *testing.T) {
 		t.Errorf("Overwrite = %v, want %v", *files[0].Overwrite, overwriteFalse)
 	}
 }
+
+func TestApplyChecksumLogic(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "structuresmith-checksum-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	renderedContent := []byte("rendered content")
+	if err := os.WriteFile(filepath.Join(tmpDir, "unchanged.txt"), renderedContent, 0o644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "edited.txt"), []byte("edited by hand"), 0o644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "orphaned.txt"), renderedContent, 0o644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "orphaned-edited.txt"), []byte("edited by hand"), 0o644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	app := &Structuresmith{
+		OutputDir: tmpDir,
+	}
+
+	lock := &AnvilLock{Files: []AnvilLockFileEntry{
+		{Path: "unchanged.txt", Checksum: computeChecksum(renderedContent)},
+		{Path: "edited.txt", Checksum: computeChecksum(renderedContent)},
+		{Path: "legacy.txt"},
+		{Path: "missing.txt", Checksum: computeChecksum(renderedContent)},
+		{Path: "orphaned.txt", Checksum: computeChecksum(renderedContent)},
+		{Path: "orphaned-edited.txt", Checksum: computeChecksum(renderedContent)},
+	}}
+
+	diff := DiffResult{
+		KeptFiles: []FileStructure{
+			{Destination: "unchanged.txt"},
+			{Destination: "edited.txt"},
+			{Destination: "legacy.txt"},
+			{Destination: "missing.txt"},
+		},
+		DeletedFiles: []FileStructure{
+			{Destination: "orphaned.txt"},
+			{Destination: "orphaned-edited.txt"},
+		},
+	}
+
+	result := app.applyChecksumLogic(diff, lock)
+
+	if len(result.ModifiedFiles) != 1 || result.ModifiedFiles[0].Destination != "edited.txt" {
+		t.Errorf("ModifiedFiles = %v, want [edited.txt]", result.ModifiedFiles)
+	}
+	if len(result.KeptFiles) != 3 {
+		t.Errorf("KeptFiles count = %d, want 3", len(result.KeptFiles))
+	}
+	if len(result.DeletedFiles) != 1 || result.DeletedFiles[0].Destination != "orphaned.txt" {
+		t.Errorf("DeletedFiles = %v, want [orphaned.txt]", result.DeletedFiles)
+	}
+	if len(result.ModifiedDeletedFiles) != 1 || result.ModifiedDeletedFiles[0].Destination != "orphaned-edited.txt" {
+		t.Fatalf("ModifiedDeletedFiles = %v, want [orphaned-edited.txt]", result.ModifiedDeletedFiles)
+	}
+	if result.ModifiedDeletedFiles[0].Checksum != computeChecksum(renderedContent) {
+		t.Errorf("ModifiedDeletedFiles checksum = %q, want the checksum of the lock file", result.ModifiedDeletedFiles[0].Checksum)
+	}
+}
+
+func TestDeleteOrphanedFileStructuresKeepsModifiedFiles(t *testing.T) {
+	tests := []struct {
+		name  string
+		force bool
+	}{
+		{name: "Without Force", force: false},
+		{name: "With Force", force: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			for _, name := range []string{"orphaned.txt", "edited.txt"} {
+				if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(name), 0o644); err != nil {
+					t.Fatalf("Failed to create file: %v", err)
+				}
+			}
+
+			app := &Structuresmith{OutputDir: tmpDir, Force: tt.force}
+			diff := DiffResult{
+				DeletedFiles:         []FileStructure{{Destination: "orphaned.txt"}},
+				ModifiedDeletedFiles: []FileStructure{{Destination: "edited.txt"}},
+			}
+			if err := app.deleteOrphanedFileStructures(diff); err != nil {
+				t.Fatalf("deleteOrphanedFileStructures() error = %v", err)
+			}
+
+			if app.fileExistsOnDisk("orphaned.txt") {
+				t.Error("orphaned.txt was not deleted")
+			}
+			if got := app.fileExistsOnDisk("edited.txt"); got == tt.force {
+				t.Errorf("edited.txt exists = %v, want %v", got, !tt.force)
+			}
+		})
+	}
+}
+
+func TestApplyContentLogic(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "structuresmith-content-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err := os.WriteFile(filepath.Join(tmpDir, "same.txt"), []byte("hello world"), 0o644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "changed.txt"), []byte("old\n"), 0o644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	app := &Structuresmith{
+		OutputDir: tmpDir,
+	}
+
+	diff := DiffResult{
+		NewFiles: []FileStructure{
+			{Destination: "new.txt", Content: "new"},
+		},
+		KeptFiles: []FileStructure{
+			{Destination: "same.txt", Content: "hello {{ .name }}", Values: map[string]any{"name": "world"}},
+			{Destination: "changed.txt", Content: "new\n"},
+			{Destination: "deleted-from-disk.txt", Content: "recreated\n"},
+		},
+	}
+
+	result, err := app.applyContentLogic(diff)
+	if err != nil {
+		t.Fatalf("applyContentLogic() error = %v", err)
+	}
+
+	if len(result.UnchangedFiles) != 1 || result.UnchangedFiles[0].Destination != "same.txt" {
+		t.Errorf("UnchangedFiles = %v, want [same.txt]", result.UnchangedFiles)
+	}
+	if len(result.KeptFiles) != 2 {
+		t.Errorf("KeptFiles count = %d, want 2", len(result.KeptFiles))
+	}
+
+	wantPatch := "--- a/changed.txt\n+++ b/changed.txt\n@@ -1 +1 @@\n-old\n+new\n"
+	if got := result.Patches["changed.txt"]; got != wantPatch {
+		t.Errorf("Patches[changed.txt] = %q, want %q", got, wantPatch)
+	}
+	if _, exists := result.Patches["deleted-from-disk.txt"]; !exists {
+		t.Error("Expected a patch for a kept file missing on disk")
+	}
+	if result.NewFiles[0].Checksum != computeChecksum([]byte("new")) {
+		t.Errorf("NewFiles checksum = %q, want checksum of rendered content", result.NewFiles[0].Checksum)
+	}
+}
+
+func TestForProjects(t *testing.T) {
+	tests := []struct {
+		name      string
+		outputDir string
+		projects  []Project
+		want      []string
+		wantErr   bool
+	}{
+		{
+			name:      "Single Project Without Pattern",
+			outputDir: "out",
+			projects:  []Project{{Name: "example/repo1"}},
+			want:      []string{"out"},
+		},
+		{
+			name:      "Multiple Projects With Pattern",
+			outputDir: "repos/{{ .Project.Name }}",
+			projects:  []Project{{Name: "example/repo1"}, {Name: "example/repo2"}},
+			want:      []string{filepath.Join("repos", "example", "repo1"), filepath.Join("repos", "example", "repo2")},
+		},
+		{
+			name:      "Multiple Projects Sharing Output Directory",
+			outputDir: "out",
+			projects:  []Project{{Name: "example/repo1"}, {Name: "example/repo2"}},
+			wantErr:   true,
+		},
+		{
+			name:      "Project Output Directories",
+			outputDir: "",
+			projects:  []Project{{Name: "example/repo1", Output: "services/repo1"}, {Name: "example/repo2"}},
+			want:      []string{filepath.Join("services", "repo1"), DefaultOutputDir},
+		},
+		{
+			name:      "Command Line Takes Precedence Over Project Output",
+			outputDir: "elsewhere",
+			projects:  []Project{{Name: "example/repo1", Output: "services/repo1"}},
+			want:      []string{"elsewhere"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := &Structuresmith{OutputDir: tt.outputDir}
+			got, err := app.forProjects(tt.projects)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("forProjects() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("forProjects() returned %d instances, want %d", len(got), len(tt.want))
+			}
+			for i := range got {
+				if got[i].OutputDir != tt.want[i] {
+					t.Errorf("OutputDir = %s, want %s", got[i].OutputDir, tt.want[i])
+				}
+			}
+			if app.OutputDir != tt.outputDir {
+				t.Errorf("forProjects() modified the original output directory")
+			}
+		})
+	}
+}
+
+func TestProcessProjectWithGroupIncludes(t *testing.T) {
+	app := &Structuresmith{}
+
+	groups := map[string][]FileStructure{
+		"common": {
+			{Destination: "LICENSE", Content: "{{ .Author }}", Values: map[string]any{"Author": "Default", "Year": "2020"}},
+		},
+		"go": {
+			{GroupName: "common", Values: map[string]any{"Year": "2023"}},
+			{Destination: "main.go", Content: "package {{ .packageName }}"},
+		},
+		"cyclic": {
+			{GroupName: "cyclic"},
+		},
+	}
+
+	project := Project{
+		Name:   "project1",
+		Groups: []TemplateGroupRef{{GroupName: "go", Values: map[string]any{"Author": "Project Author", "packageName": "main"}}},
+	}
+
+	files, err := app.processProject(project, ConfigFile{TemplateGroups: groups})
+	if err != nil {
+		t.Fatalf("processProject() error = %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("Expected 2 files, got %d", len(files))
+	}
+
+	license := files[0]
+	if license.Destination != "LICENSE" {
+		t.Fatalf("Expected LICENSE first, got %s", license.Destination)
+	}
+	if license.Values["Author"] != "Project Author" {
+		t.Errorf("Author = %v, want value of the project group reference", license.Values["Author"])
+	}
+	if license.Values["Year"] != "2023" {
+		t.Errorf("Year = %v, want value of the include entry", license.Values["Year"])
+	}
+	if license.Values["packageName"] != "main" {
+		t.Errorf("packageName = %v, want value passed down from the project", license.Values["packageName"])
+	}
+
+	_, err = app.processProject(Project{Groups: []TemplateGroupRef{{GroupName: "cyclic"}}}, ConfigFile{TemplateGroups: groups})
+	if err == nil {
+		t.Error("processProject() expected error for cyclic group include")
+	}
+}
+
+func TestProcessProjectValuePrecedence(t *testing.T) {
+	app := &Structuresmith{}
+
+	cfg := ConfigFile{
+		Globals: map[string]any{"Author": "Global", "Year": "2020", "License": "MIT", "Team": "global"},
+		TemplateGroups: map[string][]FileStructure{
+			"common": {
+				{Destination: "LICENSE", Content: "{{ .License }}", Values: map[string]any{"License": "Apache-2.0", "Year": "2021", "Team": "group"}},
+			},
+		},
+	}
+	project := Project{
+		Name:   "project1",
+		Values: map[string]any{"Year": "2024", "Team": "project"},
+		Files: []FileStructure{
+			{Destination: "README.md", Content: "{{ .Author }}", Values: map[string]any{"Author": "File"}},
+		},
+		Groups: []TemplateGroupRef{{GroupName: "common", Values: map[string]any{"Team": "ref"}}},
+	}
+
+	files, err := app.processProject(project, cfg)
+	if err != nil {
+		t.Fatalf("processProject() error = %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("Expected 2 files, got %d", len(files))
+	}
+
+	want := map[string]map[string]any{
+		"README.md": {"Author": "File", "Year": "2024", "License": "MIT", "Team": "project"},
+		"LICENSE":   {"Author": "Global", "Year": "2024", "License": "Apache-2.0", "Team": "ref"},
+	}
+	for _, file := range files {
+		delete(file.Values, SmithValuesKey)
+		if !reflect.DeepEqual(file.Values, want[file.Destination]) {
+			t.Errorf("Values of %s = %v, want %v", file.Destination, file.Values, want[file.Destination])
+		}
+	}
+}
+
+func TestMergeValues(t *testing.T) {
+	got := mergeValues(
+		map[string]any{"a": 1, "nested": map[string]any{"x": 1, "y": 1}},
+		nil,
+		map[string]any{"b": 2, "nested": map[string]any{"y": 2}},
+		map[string]any{"a": 3, "list": []any{1}},
+	)
+	want := map[string]any{"a": 3, "b": 2, "list": []any{1}, "nested": map[string]any{"x": 1, "y": 2}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mergeValues() = %v, want %v", got, want)
+	}
+}
+
+func TestRenderContentStrict(t *testing.T) {
+	tests := []struct {
+		name    string
+		strict  bool
+		content string
+		want    string
+		wantErr string
+	}{
+		{name: "Missing Key", content: "Hello {{ .Autor }}", want: "Hello <no value>"},
+		{name: "Missing Key Strict", strict: true, content: "Hello {{ .Autor }}", wantErr: `README.md:1:9: at <.Autor>: map has no entry for key "Autor"`},
+		{name: "Parse Error Copies Content", content: "Hello\n{{ .Author ", want: "Hello\n{{ .Author "},
+		{name: "Parse Error Strict", strict: true, content: "Hello\n{{ .Author ", wantErr: "README.md:2: unclosed action"},
+		{name: "Valid Template Strict", strict: true, content: "Hello {{ .Author }}", want: "Hello Alice"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := &Structuresmith{Strict: tt.strict}
+			file := FileStructure{Destination: "README.md", Content: tt.content, Values: map[string]any{"Author": "Alice"}}
+
+			got, err := app.renderContent(file)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("renderContent() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("renderContent() unexpected error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("renderContent() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderStrictWritesNothingOnError(t *testing.T) {
+	tmpDir := t.TempDir()
+	app := &Structuresmith{OutputDir: tmpDir, Strict: true}
+	cfg := ConfigFile{Projects: []ProjectConfig{{
+		Name: "project1",
+		Files: []FileStructure{
+			{Destination: "valid.txt", Content: "{{ .Author }}", Values: map[string]any{"Author": "Alice"}},
+			{Destination: "first.txt", Content: "{{ .Autor }}"},
+			{Destination: "second.txt", Content: "{{ .Year "},
+		},
+	}}}
+
+	_, err := app.render("project1", cfg)
+	if err == nil {
+		t.Fatal("render() expected error in strict mode")
+	}
+	for _, want := range []string{"first.txt:1:3:", "second.txt:1:"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("render() error = %v, want it to contain %q", err, want)
+		}
+	}
+
+	for _, name := range []string{"valid.txt", "first.txt", "second.txt"} {
+		if _, err := os.Stat(filepath.Join(tmpDir, name)); !os.IsNotExist(err) {
+			t.Errorf("render() wrote %s, want no files to be written", name)
+		}
+	}
+}
+
+func TestRenderContentRawAndBinary(t *testing.T) {
+	tmpDir := t.TempDir()
+	binary := []byte{0x89, 'P', 'N', 'G', 0x00, '{', '{', ' ', '.', 'X', ' ', '}', '}'}
+	binaryPath := filepath.Join(tmpDir, "logo.png")
+	if err := os.WriteFile(binaryPath, binary, 0o644); err != nil {
+		t.Fatalf("Failed to write binary file: %v", err)
+	}
+
+	enabled, disabled := true, false
+	workflow := "run: echo ${{ github.sha }} {{ .Name }}"
+	values := map[string]any{"Name": "demo"}
+
+	tests := []struct {
+		name   string
+		file   FileStructure
+		strict bool
+		want   string
+	}{
+		{name: "Text Is Templated", file: FileStructure{Destination: "a.txt", Content: "{{ .Name }}", Values: values}, want: "demo"},
+		{name: "Template Disabled", file: FileStructure{Destination: "ci.yml", Content: workflow, Values: values, Template: &disabled}, strict: true, want: workflow},
+		{name: "Binary Is Copied", file: FileStructure{Destination: "logo.png", Source: binaryPath, Values: values}, strict: true, want: string(binary)},
+		{name: "Template Forced On Binary", file: FileStructure{Destination: "logo.png", Source: binaryPath, Values: map[string]any{"X": "y"}, Template: &enabled}, want: "\x89PNG\x00y"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := &Structuresmith{Strict: tt.strict}
+			got, err := app.renderContent(tt.file)
+			if err != nil {
+				t.Fatalf("renderContent() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("renderContent() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcessDirectoryRawPatterns(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"README.md", ".img/logo.png", ".github/workflows/ci.yml", "docs/ci.yml"} {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte("content"), 0o644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+
+	app := &Structuresmith{}
+	files, err := app.processDirectory(FileStructure{
+		Destination: "out",
+		Source:      tmpDir,
+		Raw:         []string{"*.png", ".github/workflows/*.yml"},
+	})
+	if err != nil {
+		t.Fatalf("processDirectory() error = %v", err)
+	}
+
+	raw := make(map[string]bool)
+	for _, file := range files {
+		raw[file.Destination] = file.Template != nil && !*file.Template
+	}
+	want := map[string]bool{
+		filepath.Join("out", "README.md"):                false,
+		filepath.Join("out", ".img/logo.png"):            true,
+		filepath.Join("out", ".github/workflows/ci.yml"): true,
+		filepath.Join("out", "docs/ci.yml"):              false,
+	}
+	if !reflect.DeepEqual(raw, want) {
+		t.Errorf("processDirectory() raw files = %v, want %v", raw, want)
+	}
+}
+
+func TestIsBinary(t *testing.T) {
+	tests := []struct {
+		name    string
+		content []byte
+		want    bool
+	}{
+		{name: "Empty", content: nil, want: false},
+		{name: "Text", content: []byte("hello {{ .World }}\n"), want: false},
+		{name: "NUL Byte", content: []byte("PK\x03\x04\x00\x00"), want: true},
+		{name: "NUL Byte After Sniff Length", content: append(bytes.Repeat([]byte("a"), binarySniffLen), 0), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isBinary(tt.content); got != tt.want {
+				t.Errorf("isBinary() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcessProjectDelims(t *testing.T) {
+	app := &Structuresmith{}
+	square, angle, paren := []string{"[[", "]]"}, []string{"<<", ">>"}, []string{"((", "))"}
+
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "chart.yaml"), []byte("name: [[ .name ]]"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	cfg := ConfigFile{TemplateGroups: map[string][]FileStructure{
+		"workflows": {
+			{Destination: "ci.yml", Content: "ci"},
+			{Destination: "release.yml", Content: "release", Delims: paren},
+		},
+		"helm": {
+			{GroupName: "workflows", Delims: angle},
+			{Destination: "charts", Source: tmpDir},
+		},
+	}}
+	project := Project{
+		Name:   "project1",
+		Delims: square,
+		Files:  []FileStructure{{Destination: "README.md", Content: "readme"}},
+		Groups: []TemplateGroupRef{{GroupName: "helm"}},
+	}
+
+	files, err := app.processProject(project, cfg)
+	if err != nil {
+		t.Fatalf("processProject() error = %v", err)
+	}
+
+	got := make(map[string][]string)
+	for _, file := range files {
+		got[file.Destination] = file.Delims
+	}
+	want := map[string][]string{
+		"README.md":                           square,
+		"ci.yml":                              angle,
+		"release.yml":                         paren,
+		filepath.Join("charts", "chart.yaml"): square,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("processProject() delims = %v, want %v", got, want)
+	}
+}
+
+func TestRenderContentWithDelims(t *testing.T) {
+	app := &Structuresmith{
+		Strict:   true,
+		Partials: Partials{"header.tmpl": "# {{ .name }}"},
+	}
+	file := FileStructure{
+		Destination: ".github/workflows/ci.yml",
+		Content:     "[[ include \"header.tmpl\" . ]]\nname: [[ .name ]]\nrun: echo ${{ github.sha }}",
+		Values:      map[string]any{"name": "ci"},
+		Delims:      []string{"[[", "]]"},
+	}
+
+	got, err := app.renderContent(file)
+	if err != nil {
+		t.Fatalf("renderContent() error = %v", err)
+	}
+	if want := "# ci\nname: ci\nrun: echo ${{ github.sha }}"; string(got) != want {
+		t.Errorf("renderContent() = %q, want %q", got, want)
+	}
+}
+
+func TestRenderDestination(t *testing.T) {
+	values := map[string]any{"binaryName": "smith", "dir": "../.."}
+
+	tests := []struct {
+		name    string
+		file    FileStructure
+		want    string
+		wantErr bool
+	}{
+		{name: "Literal Destination", file: FileStructure{Destination: "cmd/main.go"}, want: "cmd/main.go"},
+		{name: "Templated Destination", file: FileStructure{Destination: "cmd/{{ .binaryName }}/main.go", Values: values}, want: "cmd/smith/main.go"},
+		{name: "Template Functions", file: FileStructure{Destination: "{{ .binaryName | upper }}.md", Values: values}, want: "SMITH.md"},
+		{name: "Custom Delims", file: FileStructure{Destination: "cmd/[[ .binaryName ]]/{{ x }}.go", Values: values, Delims: []string{"[[", "]]"}}, want: "cmd/smith/{{ x }}.go"},
+		{name: "Missing Value", file: FileStructure{Destination: "cmd/{{ .missing }}/main.go", Values: values}, wantErr: true},
+		{name: "Escapes Output Directory", file: FileStructure{Destination: "{{ .dir }}/etc/passwd", Values: values}, wantErr: true},
+		{name: "Literal Parent Directory", file: FileStructure{Destination: "../outside.txt"}, wantErr: true},
+		{name: "Absolute Path", file: FileStructure{Destination: "/etc/passwd"}, wantErr: true},
+		{name: "Output Directory Itself", file: FileStructure{Destination: "{{ if false }}x{{ end }}", Values: values}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := renderDestination(tt.file)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("renderDestination() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("renderDestination() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReplacePathPlaceholders(t *testing.T) {
+	values := map[string]any{"packageName": "smith", "version": 2, "nested": map[string]any{"a": 1}}
+
+	tests := []struct {
+		relPath string
+		want    string
+	}{
+		{relPath: "__packageName__/main.go", want: "smith/main.go"},
+		{relPath: "pkg/__packageName___v__version__.go", want: "pkg/smith_v2.go"},
+		{relPath: "__init__.py", want: "__init__.py"},
+		{relPath: "__nested__/file", want: "__nested__/file"},
+		{relPath: "plain/file.txt", want: "plain/file.txt"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.relPath, func(t *testing.T) {
+			if got := replacePathPlaceholders(tt.relPath, values); got != tt.want {
+				t.Errorf("replacePathPlaceholders() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcessProjectTemplatedDestinations(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "__packageName__", "{{ .packageName }}_test.go")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte("package {{ .packageName }}"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	app := &Structuresmith{}
+	project := Project{
+		Name:   "project1",
+		Values: map[string]any{"packageName": "smith"},
+		Files: []FileStructure{
+			{Destination: "pkg", Source: tmpDir},
+			{Destination: "docs/{{ .Smith.Project.Name }}.md", Content: "{{ .Smith.File.Destination }}"},
+		},
+	}
+
+	files, err := app.processProject(project, ConfigFile{})
+	if err != nil {
+		t.Fatalf("processProject() error = %v", err)
+	}
+
+	var destinations []string
+	for _, file := range files {
+		destinations = append(destinations, file.Destination)
+	}
+	want := []string{filepath.Join("pkg", "smith", "smith_test.go"), "docs/project1.md"}
+	if !reflect.DeepEqual(destinations, want) {
+		t.Errorf("processProject() destinations = %v, want %v", destinations, want)
+	}
+
+	smith := files[1].Values[SmithValuesKey].(SmithContext)
+	if smith.File.Destination != "docs/project1.md" {
+		t.Errorf("Smith.File.Destination = %q, want the rendered destination", smith.File.Destination)
+	}
+}
+
+func TestEvaluateWhen(t *testing.T) {
+	values := map[string]any{"enableDocker": true, "disabled": false, "count": 0, "mode": "Off", "name": "smith"}
+
+	tests := []struct {
+		name    string
+		expr    string
+		delims  []string
+		strict  bool
+		want    bool
+		wantErr bool
+	}{
+		{name: "Empty Expression", expr: "", want: true},
+		{name: "True Value", expr: "{{ .enableDocker }}", want: true},
+		{name: "False Value", expr: "{{ .disabled }}", want: false},
+		{name: "Zero", expr: "{{ .count }}", want: false},
+		{name: "Off Ignoring Case", expr: " {{ .mode }} ", want: false},
+		{name: "Missing Value", expr: "{{ .missing }}", want: false},
+		{name: "Missing Value In Strict Mode", expr: "{{ .missing }}", strict: true, wantErr: true},
+		{name: "Present Value In Strict Mode", expr: "{{ .enableDocker }}", strict: true, want: true},
+		{name: "Comparison", expr: `{{ eq .name "smith" }}`, want: true},
+		{name: "Negation", expr: "{{ not .enableDocker }}", want: false},
+		{name: "Custom Delims", expr: "[[ .enableDocker ]]", delims: []string{"[[", "]]"}, want: true},
+		{name: "Literal No", expr: "no", want: false},
+		{name: "Invalid Expression", expr: "{{ .enableDocker ", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := evaluateWhen(tt.expr, values, tt.delims, tt.strict)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("evaluateWhen() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("evaluateWhen() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcessProjectWhen(t *testing.T) {
+	app := &Structuresmith{}
+	cfg := ConfigFile{TemplateGroups: map[string][]FileStructure{
+		"docker": {
+			{Destination: "Dockerfile", Content: "FROM scratch"},
+			{Destination: "compose.yml", Content: "services: {}", When: "{{ .enableCompose }}"},
+		},
+		"features": {
+			{GroupName: "docker", When: "{{ .enableDocker }}"},
+			{Destination: "docs.md", Content: "docs", When: "{{ .enableDocs }}"},
+		},
+	}}
+
+	tests := []struct {
+		name    string
+		project Project
+		want    []string
+	}{
+		{
+			name: "All Features Disabled",
+			project: Project{
+				Name:   "project1",
+				Groups: []TemplateGroupRef{{GroupName: "features"}},
+			},
+			want: nil,
+		},
+		{
+			name: "Included Group Enabled",
+			project: Project{
+				Name:   "project1",
+				Values: map[string]any{"enableDocker": true},
+				Groups: []TemplateGroupRef{{GroupName: "features", Values: map[string]any{"enableDocs": "yes"}}},
+			},
+			want: []string{"Dockerfile", "docs.md"},
+		},
+		{
+			name: "Project File And Group Reference",
+			project: Project{
+				Name:   "project1",
+				Values: map[string]any{"enableDocker": true, "enableCompose": true, "ci": "off"},
+				Files:  []FileStructure{{Destination: "ci.yml", Content: "ci", When: "{{ .ci }}"}},
+				Groups: []TemplateGroupRef{
+					{GroupName: "features"},
+					{GroupName: "docker", When: "{{ .enableDocs }}"},
+				},
+			},
+			want: []string{"Dockerfile", "compose.yml"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := app.processProject(tt.project, cfg)
+			if err != nil {
+				t.Fatalf("processProject() error = %v", err)
+			}
+			var got []string
+			for _, file := range files {
+				got = append(got, file.Destination)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("processProject() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluateForEach(t *testing.T) {
+	values := map[string]any{
+		"environments": []any{"dev", "prod"},
+		"regions":      map[string]any{"us": "us-east-1", "eu": "eu-west-1"},
+		"name":         "smith",
+	}
+
+	tests := []struct {
+		name        string
+		expr        string
+		delims      []string
+		strict      bool
+		wantIndexes []any
+		wantItems   []any
+		wantErr     bool
+	}{
+		{name: "List", expr: ".environments", wantIndexes: []any{0, 1}, wantItems: []any{"dev", "prod"}},
+		{name: "List With Delimiters", expr: "{{ .environments }}", wantIndexes: []any{0, 1}, wantItems: []any{"dev", "prod"}},
+		{name: "Custom Delimiters", expr: "[[ .environments ]]", delims: []string{"[[", "]]"}, wantIndexes: []any{0, 1}, wantItems: []any{"dev", "prod"}},
+		{name: "Dictionary Ordered By Key", expr: ".regions", wantIndexes: []any{"eu", "us"}, wantItems: []any{"eu-west-1", "us-east-1"}},
+		{name: "Template Function", expr: `list "a" "b" "c" | rest`, wantIndexes: []any{0, 1}, wantItems: []any{"b", "c"}},
+		{name: "Missing Value", expr: ".missing"},
+		{name: "Missing Value In Strict Mode", expr: ".missing", strict: true, wantErr: true},
+		{name: "List In Strict Mode", expr: ".environments", strict: true, wantIndexes: []any{0, 1}, wantItems: []any{"dev", "prod"}},
+		{name: "Not A List", expr: ".name", wantErr: true},
+		{name: "Invalid Expression", expr: "(.environments", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			indexes, items, err := evaluateForEach(tt.expr, values, tt.delims, tt.strict)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("evaluateForEach() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(indexes, tt.wantIndexes) {
+				t.Errorf("evaluateForEach() indexes = %v, want %v", indexes, tt.wantIndexes)
+			}
+			if !reflect.DeepEqual(items, tt.wantItems) {
+				t.Errorf("evaluateForEach() items = %v, want %v", items, tt.wantItems)
+			}
+		})
+	}
+}
+
+func TestProcessProjectForEach(t *testing.T) {
+	app := &Structuresmith{}
+	cfg := ConfigFile{TemplateGroups: map[string][]FileStructure{
+		"deploy": {
+			{
+				Destination: "deploy/{{ .item.name }}.yaml",
+				Content:     "replicas: {{ .item.replicas }}",
+				ForEach:     ".environments",
+				When:        `{{ ne .item.name "skip" }}`,
+			},
+		},
+	}}
+	project := Project{
+		Name: "project1",
+		Values: map[string]any{"environments": []any{
+			map[string]any{"name": "dev", "replicas": 1},
+			map[string]any{"name": "skip", "replicas": 0},
+			map[string]any{"name": "prod", "replicas": 3},
+		}},
+		Groups: []TemplateGroupRef{{GroupName: "deploy"}},
+	}
+
+	files, err := app.processProject(project, cfg)
+	if err != nil {
+		t.Fatalf("processProject() error = %v", err)
+	}
+
+	var destinations []string
+	var indexes []any
+	for _, file := range files {
+		destinations = append(destinations, file.Destination)
+		indexes = append(indexes, file.Values["index"])
+	}
+	if want := []string{"deploy/dev.yaml", "deploy/prod.yaml"}; !reflect.DeepEqual(destinations, want) {
+		t.Errorf("processProject() destinations = %v, want %v", destinations, want)
+	}
+	if want := []any{0, 2}; !reflect.DeepEqual(indexes, want) {
+		t.Errorf("processProject() indexes = %v, want %v", indexes, want)
+	}
+
+	t.Run("Literal Destination", func(t *testing.T) {
+		_, err := app.processProject(Project{
+			Name:   "project1",
+			Values: project.Values,
+			Files:  []FileStructure{{Destination: "deploy.yaml", Content: "x", ForEach: ".environments"}},
+		}, ConfigFile{})
+		if err == nil {
+			t.Error("processProject() expected error for forEach with a literal destination")
+		}
+	})
+}
+
+func TestProcessProjectOverrides(t *testing.T) {
+	app := &Structuresmith{Overrides: map[string]any{"image": map[string]any{"tag": "v2"}}}
+	cfg := ConfigFile{TemplateGroups: map[string][]FileStructure{
+		"deploy": {{Destination: "deploy.yaml", Content: "x", Values: map[string]any{"image": map[string]any{"name": "app", "tag": "group"}}}},
+	}}
+	project := Project{
+		Name:   "project1",
+		Files:  []FileStructure{{Destination: "README.md", Content: "x", Values: map[string]any{"image": map[string]any{"tag": "file"}}}},
+		Groups: []TemplateGroupRef{{GroupName: "deploy", Values: map[string]any{"image": map[string]any{"tag": "ref"}}}},
+	}
+
+	files, err := app.processProject(project, cfg)
+	if err != nil {
+		t.Fatalf("processProject() error = %v", err)
+	}
+
+	want := map[string]map[string]any{
+		"README.md":   {"tag": "v2"},
+		"deploy.yaml": {"name": "app", "tag": "v2"},
+	}
+	for _, file := range files {
+		if got := file.Values["image"]; !reflect.DeepEqual(got, want[file.Destination]) {
+			t.Errorf("image of %s = %v, want %v", file.Destination, got, want[file.Destination])
+		}
+	}
+}
+
+func TestProcessProjectStrictMissingValues(t *testing.T) {
+	cfg := ConfigFile{TemplateGroups: map[string][]FileStructure{
+		"deploy": {{Destination: "deploy/{{ .item }}.yaml", Content: "x", ForEach: ".enviroments"}},
+		"docker": {{Destination: "Dockerfile", Content: "x", When: "{{ .enableDokcer }}"}},
+	}}
+	project := Project{
+		Name:   "project1",
+		Values: map[string]any{"environments": []any{"dev", "prod"}, "enableDocker": true},
+	}
+
+	for _, groupName := range []string{"deploy", "docker"} {
+		t.Run(groupName, func(t *testing.T) {
+			project.Groups = []TemplateGroupRef{{GroupName: groupName}}
+
+			files, err := (&Structuresmith{}).processProject(project, cfg)
+			if err != nil || len(files) != 0 {
+				t.Errorf("processProject() = %v, %v, want no files without strict mode", files, err)
+			}
+			if _, err := (&Structuresmith{Strict: true}).processProject(project, cfg); err == nil {
+				t.Error("processProject() expected error for missing value in strict mode")
+			}
+		})
+	}
+}
+
+func TestDelimsOrDefault(t *testing.T) {
+	tests := []struct {
+		name      string
+		delims    []string
+		wantLeft  string
+		wantRight string
+	}{
+		{name: "No Delimiters", wantLeft: "{{", wantRight: "}}"},
+		{name: "Custom Delimiters", delims: []string{"[[", "]]"}, wantLeft: "[[", wantRight: "]]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			left, right := delimsOrDefault(tt.delims)
+			if left != tt.wantLeft || right != tt.wantRight {
+				t.Errorf("delimsOrDefault() = %q, %q, want %q, %q", left, right, tt.wantLeft, tt.wantRight)
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/fatih/color"
+)
+
+// DriftStatus constants representing the drift of a file on disk from the configuration.
+type DriftStatus string
+
+const (
+	DriftMissing  DriftStatus = "Missing"
+	DriftOutdated DriftStatus = "Outdated"
+	DriftOrphaned DriftStatus = "Orphaned"
+	DriftModified DriftStatus = "Modified"
+)
+
+// CheckResult represents the drift between the rendered project and the files on disk.
+type CheckResult struct {
+	MissingFiles  []FileStructure // Managed files that do not exist on disk.
+	OutdatedFiles []FileStructure // Managed files whose content on disk differs from the rendered content.
+	OrphanedFiles []FileStructure // Files in AnvilLock that are no longer managed but still exist on disk.
+	ModifiedFiles []FileStructure // Managed files that were edited by hand since the last render.
+	UpToDateFiles []FileStructure // Managed files whose content on disk matches the rendered content.
+}
+
+// Summary returns a single line with the number of files per drift status.
+func (c CheckResult) Summary() string {
+	return fmt.Sprintf("missing=%d outdated=%d orphaned=%d modified=%d",
+		len(c.MissingFiles), len(c.OutdatedFiles), len(c.OrphanedFiles), len(c.ModifiedFiles))
+}
+
+func (c CheckResult) String() string {
+	fileMap := make(map[string]DriftStatus)
+
+	// Populate the map
+	for _, file := range c.MissingFiles {
+		fileMap[file.Destination] = DriftMissing
+	}
+	for _, file := range c.OutdatedFiles {
+		fileMap[file.Destination] = DriftOutdated
+	}
+	for _, file := range c.OrphanedFiles {
+		fileMap[file.Destination] = DriftOrphaned
+	}
+	for _, file := range c.ModifiedFiles {
+		fileMap[file.Destination] = DriftModified
+	}
+
+	// Sort the keys (file paths)
+	keys := make([]string, 0, len(fileMap))
+	for key := range fileMap {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var result strings.Builder
+	writer := tabwriter.NewWriter(&result, 0, 8, 2, ' ', 0)
+
+	for _, key := range keys {
+		prefix := getDriftColorAndPrefix(fileMap[key])
+		_, _ = fmt.Fprintf(writer, "%s\t%s\n", prefix, key)
+	}
+
+	if err := writer.Flush(); err != nil {
+		return "Error generating output"
+	}
+
+	result.WriteString("\n")
+	result.WriteString(c.Summary())
+	result.WriteString("\n")
+	return result.String()
+}
+
+func getDriftColorAndPrefix(status DriftStatus) string {
+	switch status {
+	case DriftMissing:
+		return color.New(color.FgRed).Sprintf("missing:")
+	case DriftOutdated:
+		return color.New(color.FgYellow).Sprintf("outdated:")
+	case DriftOrphaned:
+		return color.New(color.FgRed).Sprintf("orphaned:")
+	case DriftModified:
+		return color.New(color.FgMagenta).Sprintf("modified:")
+	default:
+		return "n/a: "
+	}
+}
+
+// detectDrift renders the files of a DiffResult in memory and compares them against the output directory.
+// Skipped files are ignored, since their content is owned by the user.
+func (app *Structuresmith) detectDrift(diff DiffResult) (CheckResult, error) {
+	var result CheckResult
+	var errs []error
+
+	managedFiles := append(append([]FileStructure{}, diff.NewFiles...), diff.KeptFiles...)
+	for _, file := range managedFiles {
+		status, err := app.compareWithDisk(&file)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		switch status {
+		case DriftMissing:
+			result.MissingFiles = append(result.MissingFiles, file)
+		case DriftOutdated:
+			result.OutdatedFiles = append(result.OutdatedFiles, file)
+		default:
+			result.UpToDateFiles = append(result.UpToDateFiles, file)
+		}
+	}
+
+	// Hand-edited files only count as drift if they no longer match the rendered content
+	for _, file := range diff.ModifiedFiles {
+		status, err := app.compareWithDisk(&file)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if status != "" {
+			result.ModifiedFiles = append(result.ModifiedFiles, file)
+		} else {
+			result.UpToDateFiles = append(result.UpToDateFiles, file)
+		}
+	}
+
+	for _, file := range diff.DeletedFiles {
+		if app.fileExistsOnDisk(file.Destination) {
+			result.OrphanedFiles = append(result.OrphanedFiles, file)
+		}
+	}
+	result.ModifiedFiles = append(result.ModifiedFiles, diff.ModifiedDeletedFiles...)
+
+	if len(errs) > 0 {
+		return CheckResult{}, errors.Join(errs...)
+	}
+	return result, nil
+}
+
+// compareWithDisk renders a file in memory, records its checksum and compares it with its
+// counterpart in the output directory. It returns an empty DriftStatus if both are identical.
+func (app *Structuresmith) compareWithDisk(file *FileStructure) (DriftStatus, error) {
+	rendered, err := app.renderContent(*file)
+	if err != nil {
+		return "", err
+	}
+	file.Checksum = computeChecksum(rendered)
+
+	actual, err := os.ReadFile(filepath.Join(app.OutputDir, file.Destination))
+	if os.IsNotExist(err) {
+		return DriftMissing, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("reading %s: %w", file.Destination, err)
+	}
+
+	if !bytes.Equal(rendered, actual) {
+		return DriftOutdated, nil
+	}
+	return "", nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDetectDrift(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "structuresmith-check-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	files := map[string]string{
+		"up-to-date.txt":      "hello world",
+		"outdated.txt":        "old content",
+		"orphaned.txt":        "no longer managed",
+		"edited.txt":          "edited by hand",
+		"reverted.txt":        "hello world",
+		"skipped.txt":         "user owned",
+		"orphaned-edited.txt": "edited by hand",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+	}
+
+	app := &Structuresmith{
+		OutputDir: tmpDir,
+	}
+
+	diff := DiffResult{
+		NewFiles: []FileStructure{
+			{Destination: "missing.txt", Content: "hello world"},
+		},
+		KeptFiles: []FileStructure{
+			{Destination: "up-to-date.txt", Content: "hello {{ .name }}", Values: map[string]any{"name": "world"}},
+			{Destination: "outdated.txt", Content: "new content"},
+		},
+		DeletedFiles: []FileStructure{
+			{Destination: "orphaned.txt"},
+			{Destination: "already-deleted.txt"},
+		},
+		ModifiedFiles: []FileStructure{
+			{Destination: "edited.txt", Content: "hello world"},
+			{Destination: "reverted.txt", Content: "hello world"},
+		},
+		ModifiedDeletedFiles: []FileStructure{
+			{Destination: "orphaned-edited.txt"},
+		},
+		SkippedFiles: []FileStructure{
+			{Destination: "skipped.txt", Content: "hello world"},
+		},
+	}
+
+	result, err := app.detectDrift(diff)
+	if err != nil {
+		t.Fatalf("detectDrift() error = %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		files []FileStructure
+		want  []string
+	}{
+		{name: "Missing Files", files: result.MissingFiles, want: []string{"missing.txt"}},
+		{name: "Outdated Files", files: result.OutdatedFiles, want: []string{"outdated.txt"}},
+		{name: "Orphaned Files", files: result.OrphanedFiles, want: []string{"orphaned.txt"}},
+		{name: "Modified Files", files: result.ModifiedFiles, want: []string{"edited.txt", "orphaned-edited.txt"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if len(tt.files) != len(tt.want) {
+				t.Fatalf("got %d files, want %d", len(tt.files), len(tt.want))
+			}
+			for i := range tt.files {
+				if tt.files[i].Destination != tt.want[i] {
+					t.Errorf("item %d = %s, want %s", i, tt.files[i].Destination, tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// resolveDestinationCollisions keeps a single file for every destination.
+// A file with override: true takes precedence over files without it, followed by the file with the highest priority.
+// Files that take the same precedence are reported as a collision, listing where they are declared.
+func resolveDestinationCollisions(files []FileStructure) ([]FileStructure, error) {
+	var destinations []string
+	byDestination := make(map[string][]int)
+	for i, file := range files {
+		destination := filepath.Clean(file.Destination)
+		if _, seen := byDestination[destination]; !seen {
+			destinations = append(destinations, destination)
+		}
+		byDestination[destination] = append(byDestination[destination], i)
+	}
+
+	var errs []error
+	kept := make(map[int]bool, len(destinations))
+	for _, destination := range destinations {
+		indexes := byDestination[destination]
+		winner, tied := indexes[0], []int{indexes[0]}
+		for _, i := range indexes[1:] {
+			switch precedence := compareFilePrecedence(files[i], files[winner]); {
+			case precedence > 0:
+				winner, tied = i, []int{i}
+			case precedence == 0:
+				tied = append(tied, i)
+			}
+		}
+		if len(tied) > 1 {
+			errs = append(errs, fmt.Errorf("destination %s is declared %d times, by %s; set priority or override: true to choose one",
+				destination, len(tied), describeOrigins(files, tied)))
+			continue
+		}
+		kept[winner] = true
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	resolved := make([]FileStructure, 0, len(kept))
+	for i, file := range files {
+		if kept[i] {
+			resolved = append(resolved, file)
+		}
+	}
+	return resolved, nil
+}
+
+// compareFilePrecedence returns a positive number if a takes precedence over b, a negative number if b
+// takes precedence over a and zero if neither does.
+func compareFilePrecedence(a, b FileStructure) int {
+	if a.Override != b.Override {
+		if a.Override {
+			return 1
+		}
+		return -1
+	}
+	return cmp.Compare(a.Priority, b.Priority)
+}
+
+// describeOrigins lists where the given files are declared, e.g. "template group common and project example".
+func describeOrigins(files []FileStructure, indexes []int) string {
+	var origins []string
+	for _, i := range indexes {
+		origin := files[i].origin
+		if origin == "" {
+			origin = "an unknown origin"
+		}
+		if files[i].Source != "" {
+			origin += fmt.Sprintf(" (source %s)", files[i].Source)
+		}
+		origins = append(origins, origin)
+	}
+	if len(origins) <= 1 {
+		return strings.Join(origins, "")
+	}
+	return strings.Join(origins[:len(origins)-1], ", ") + " and " + origins[len(origins)-1]
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestResolveDestinationCollisions(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   []FileStructure
+		want    []string
+		wantErr string
+	}{
+		{
+			name:  "Distinct Destinations",
+			files: []FileStructure{{Destination: "a", Content: "a"}, {Destination: "b", Content: "b"}},
+			want:  []string{"a", "b"},
+		},
+		{
+			name: "Collision",
+			files: []FileStructure{
+				{Destination: "README.md", Content: "group", origin: "template group common"},
+				{Destination: "./README.md", Content: "project", origin: "project example"},
+			},
+			wantErr: "destination README.md is declared 2 times, by template group common and project example; set priority or override: true to choose one",
+		},
+		{
+			name: "Highest Priority Wins",
+			files: []FileStructure{
+				{Destination: "README.md", Content: "low", Priority: -1},
+				{Destination: "README.md", Content: "default"},
+				{Destination: "README.md", Content: "high", Priority: 10},
+			},
+			want: []string{"high"},
+		},
+		{
+			name: "Override Wins Over Priority",
+			files: []FileStructure{
+				{Destination: "README.md", Content: "override", Override: true},
+				{Destination: "README.md", Content: "priority", Priority: 10},
+			},
+			want: []string{"override"},
+		},
+		{
+			name: "Tied Priority",
+			files: []FileStructure{
+				{Destination: "README.md", Content: "low"},
+				{Destination: "README.md", Content: "a", Priority: 1, origin: "template group a"},
+				{Destination: "README.md", Content: "b", Priority: 1, origin: "template group b", Source: "templates/b.tmpl"},
+			},
+			wantErr: "destination README.md is declared 2 times, by template group a and template group b (source templates/b.tmpl); set priority or override: true to choose one",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := resolveDestinationCollisions(tt.files)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("resolveDestinationCollisions() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveDestinationCollisions() error = %v", err)
+			}
+			var got []string
+			for _, file := range files {
+				got = append(got, file.Content)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("resolveDestinationCollisions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcessProjectDestinationCollisions(t *testing.T) {
+	tmpDir := t.TempDir()
+	docsDir := filepath.Join(tmpDir, "docs")
+	if err := os.MkdirAll(docsDir, 0o755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(docsDir, "README.md"), []byte("docs"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	app := &Structuresmith{}
+	cfg := ConfigFile{TemplateGroups: map[string][]FileStructure{
+		"docs": {{Destination: "docs", Source: docsDir}},
+	}}
+
+	project := Project{
+		Name:   "project1",
+		Groups: []TemplateGroupRef{{GroupName: "docs"}},
+		Files:  []FileStructure{{Destination: "docs/README.md", Content: "project"}},
+	}
+	_, err := app.processProject(project, cfg)
+	want := "destination docs/README.md is declared 2 times, by project project1 and template group docs (source " + filepath.Join(docsDir, "README.md") + "); set priority or override: true to choose one"
+	if err == nil || err.Error() != want {
+		t.Errorf("processProject() error = %v, want %q", err, want)
+	}
+
+	project.Files[0].Override = true
+	files, err := app.processProject(project, cfg)
+	if err != nil {
+		t.Fatalf("processProject() error = %v", err)
+	}
+	if len(files) != 1 || files[0].Content != "project" {
+		t.Errorf("processProject() = %+v, want the overriding project file", files)
+	}
+}
+
+func TestValidateProjects(t *testing.T) {
+	app := &Structuresmith{}
+	cfg := ConfigFile{
+		filename:       "anvil.yml",
+		positions:      map[string]Position{"projects[1]": {Line: 8, Column: 5}},
+		TemplateGroups: map[string][]FileStructure{"common": {{Destination: "LICENSE", Content: "MIT"}}},
+		Projects: []ProjectConfig{
+			{Name: "base", Abstract: true, Files: []FileStructure{{Destination: "LICENSE", Content: "base"}}, Groups: []TemplateGroupRef{{GroupName: "common"}}},
+			{Name: "service", Extends: "base"},
+			{Name: "valid", Groups: []TemplateGroupRef{{GroupName: "common"}}},
+		},
+	}
+
+	err := app.validateProjects(cfg)
+	want := "anvil.yml:8:5: project service: destination LICENSE is declared 2 times, by project service and template group common; set priority or override: true to choose one"
+	if err == nil || err.Error() != want {
+		t.Errorf("validateProjects() error = %v, want %q", err, want)
+	}
+}
@@ -1,12 +1,21 @@
 package main
 
 import (
+	"bytes"
+	"cmp"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
 	"strconv"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
@@ -53,8 +62,22 @@ func (m FileMode) String() string {
 
 // ConfigFile represents the structure of the configuration file.
 type ConfigFile struct {
+	// Globals are values shared by all projects. They have the lowest precedence of all values.
+	Globals        map[string]any             `yaml:"globals"`
 	TemplateGroups map[string][]FileStructure `yaml:"templateGroups"`
-	Projects       []ProjectConfig            `yaml:"projects"`
+	// GroupSchemas declares the values expected by template groups, keyed by group name.
+	GroupSchemas map[string]ValuesSchema `yaml:"groupSchemas"`
+	Projects     []ProjectConfig         `yaml:"projects"`
+
+	// secrets holds the values resolved from environment variables and files, which are redacted from output.
+	secrets []string
+	// filename is the path of the configuration file as displayed in validation errors.
+	filename string
+	// positions holds the positions of keys and list items in the configuration file by their path.
+	positions map[string]Position
+	// problems holds the problems found while reading the configuration file.
+	// They are reported by validateConfig together with the problems found by its checks.
+	problems ValidationErrors
 }
 
 // ProjectConfig defines the configuration of a single repository.
@@ -62,27 +85,74 @@ type ProjectConfig struct {
 	Name   string             `yaml:"name"`
 	Files  []FileStructure    `yaml:"files"`
 	Groups []TemplateGroupRef `yaml:"groups"`
+	// Values are shared by all files and template groups of the project.
+	Values map[string]any `yaml:"values"`
+	// ValuesFiles are YAML or JSON files with values, relative to the configuration file.
+	// They are merged in order on top of the inline values of the project.
+	ValuesFiles []string `yaml:"valuesFiles,omitempty"`
+	// Output is the directory the project is rendered to, relative to the configuration file.
+	// It is used unless an output directory is passed on the command line.
+	Output string `yaml:"output,omitempty"`
+	// Extends names a project whose files and groups are inherited by this project.
+	Extends string `yaml:"extends,omitempty"`
+	// Abstract marks a project that only serves as a parent for other projects and cannot be rendered.
+	Abstract bool `yaml:"abstract,omitempty"`
+	// Delims are the left and right template delimiters for all files of the project, e.g. ["[[", "]]"].
+	Delims []string `yaml:"delims,omitempty"`
 }
 
 // TemplateGroupRef links a template group with specific values.
 type TemplateGroupRef struct {
 	GroupName string         `yaml:"groupName"`
 	Values    map[string]any `yaml:"values"`
+	// Delims are the template delimiters for all files of the group, unless a file declares its own.
+	Delims []string `yaml:"delims,omitempty"`
+	// When is a template expression that excludes the group if it renders to a false value.
+	When string `yaml:"when,omitempty"`
 }
 
 // FileStructure describes a file to be created from a template or URL.
+// Within a template group, it may instead reference another group by its GroupName.
 type FileStructure struct {
-	Destination string `yaml:"destination"`
-	Source      string `yaml:"source"`
-	SourceURL   string `yaml:"sourceUrl"`
-	Content     string `yaml:"content"`
-	Values      map[string]any
+	// GroupName includes all files of another template group. Values are passed down
+	// to the included group. Only valid for entries of template groups.
+	GroupName   string         `yaml:"groupName,omitempty"`
+	Destination string         `yaml:"destination"`
+	Source      string         `yaml:"source"`
+	SourceURL   string         `yaml:"sourceUrl"`
+	Content     string         `yaml:"content"`
+	Values      map[string]any `yaml:"values"`
 	// Permissions specifies the file mode for the destination file.
 	// Accepts octal strings like "0755" or "0644". Defaults to "0644" if not specified.
 	Permissions *FileMode `yaml:"permissions,omitempty"`
 	// Overwrite controls whether the file should be overwritten if it already exists.
 	// Defaults to true if not specified. Set to false to protect existing files.
 	Overwrite *bool `yaml:"overwrite,omitempty"`
+	// Template controls whether the file is rendered as a template. If not specified, text files
+	// are rendered and binary files are copied as is. Set to false to copy the file byte-for-byte.
+	Template *bool `yaml:"template,omitempty"`
+	// Delims are the left and right template delimiters of the file, e.g. ["[[", "]]"].
+	// For group includes and directory sources, they apply to all included files.
+	Delims []string `yaml:"delims,omitempty"`
+	// When is a template expression evaluated against the values of the file, e.g. '{{ .enableDocker }}'.
+	// The file is excluded if it renders to an empty string, "false", "0", "no" or "off".
+	When string `yaml:"when,omitempty"`
+	// ForEach is an expression such as ".environments" that generates one file per item of a list or dictionary.
+	// The item and its index are available as .item and .index, e.g. in a destination "deploy/{{ .item }}.yaml".
+	ForEach string `yaml:"forEach,omitempty"`
+	// Raw lists glob patterns of files within a directory source that are copied without templating.
+	// Patterns containing a slash match the path relative to the directory, others match the file name.
+	Raw []string `yaml:"raw,omitempty"`
+	// Priority resolves collisions with other files of the same destination. The file with the highest priority is rendered.
+	Priority int `yaml:"priority,omitempty"`
+	// Override marks a file that replaces other files of the same destination, regardless of their priority.
+	Override bool `yaml:"override,omitempty"`
+	// Checksum holds the SHA-256 checksum of the rendered content.
+	// It is set during rendering and recorded in the lock file.
+	Checksum string `yaml:"-"`
+
+	// origin describes where the file is declared, e.g. "template group common", to report collisions.
+	origin string
 }
 
 // Template represents a template consisting of multiple files.
@@ -98,6 +168,9 @@ type Project struct {
 	Name   string
 	Files  []FileStructure
 	Groups []TemplateGroupRef
+	Values map[string]any
+	Output string
+	Delims []string
 }
 
 // readConfig reads and parses the YAML configuration file.
@@ -108,8 +181,19 @@ func readConfig(filename, templatesDir string) (ConfigFile, error) {
 		return config, fmt.Errorf("failed to read file: %w", err)
 	}
 
-	err = yaml.Unmarshal(data, &config)
-	if err != nil {
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return config, fmt.Errorf("failed to unmarshal YAML: %w", err)
+	}
+	config.filename = displayPath(filename)
+	config.positions = indexPositions(&document)
+
+	// Report unknown keys, so that a misspelled key such as "overwite" is not silently ignored
+	config.problems = unknownKeys(&document, reflect.TypeOf(config), config.filename)
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	// Unknown keys that were already reported must not stop decoding the rest of the configuration
+	decoder.KnownFields(len(config.problems) == 0)
+	if err := decoder.Decode(&config); err != nil && !errors.Is(err, io.EOF) {
 		return config, fmt.Errorf("failed to unmarshal YAML: %w", err)
 	}
 
@@ -131,6 +215,37 @@ func readConfig(filename, templatesDir string) (ConfigFile, error) {
 		}
 	}
 
+	// Resolve project output directories relative to the configuration file
+	for i, project := range config.Projects {
+		if project.Output != "" && !filepath.IsAbs(project.Output) {
+			config.Projects[i].Output = filepath.Join(filepath.Dir(filename), project.Output)
+		}
+	}
+
+	// Merge values files of projects, relative to the configuration file, on top of their inline values
+	for i, project := range config.Projects {
+		for _, valuesFile := range project.ValuesFiles {
+			if !filepath.IsAbs(valuesFile) {
+				valuesFile = filepath.Join(filepath.Dir(filename), valuesFile)
+			}
+			values, err := readValuesFile(valuesFile)
+			if err != nil {
+				return config, fmt.Errorf("project %s: %w", project.Name, err)
+			}
+			config.Projects[i].Values = mergeValues(config.Projects[i].Values, values)
+		}
+	}
+
+	// Resolve references to environment variables and files in values.
+	// Unresolved references are reported by validateConfig with all other problems.
+	if err := config.interpolateValues(filepath.Dir(filename)); err != nil {
+		var validationErrs ValidationErrors
+		if !errors.As(err, &validationErrs) {
+			return config, err
+		}
+		config.problems = append(config.problems, validationErrs...)
+	}
+
 	log.Println("Configuration read successfully.")
 	return config, nil
 }
@@ -146,96 +261,579 @@ func (c *ConfigFile) findProjectConfig(name string) (ProjectConfig, bool) {
 }
 
 // validateConfig performs various checks on the configuration.
+// All problems found by the checks are reported at once.
 func (c *ConfigFile) validateConfig() error {
-	if err := c.validateDuplicateProjectNames(); err != nil {
-		return err
+	checks := []func() error{
+		c.validateDuplicateProjectNames,
+		c.validateDuplicateTemplateGroups,
+		c.validateFileStructures,
+		c.validateProjectGroupReferences,
+		c.validateURLSchemes,
+		c.validateDuplicateProjectOutputs,
+		c.validateProjectInheritance,
+		c.validateDelims,
+		c.validateGroupSchemas,
 	}
-	if err := c.validateDuplicateTemplateGroups(); err != nil {
-		return err
+
+	errs := slices.Clone(c.problems)
+	for _, check := range checks {
+		err := check()
+		if err == nil {
+			continue
+		}
+		var validationErrs ValidationErrors
+		if errors.As(err, &validationErrs) {
+			errs = append(errs, validationErrs...)
+		} else {
+			errs = append(errs, ValidationError{File: c.filename, Message: err.Error()})
+		}
 	}
-	if err := c.validateFileStructures(); err != nil {
-		return err
+
+	// Report problems in the order they appear in the configuration file
+	slices.SortStableFunc(errs, func(a, b ValidationError) int {
+		return cmp.Or(cmp.Compare(a.Line, b.Line), cmp.Compare(a.Column, b.Column))
+	})
+	return errs.err()
+}
+
+// validateDelims checks that custom template delimiters consist of a non-empty left and right delimiter.
+func (c *ConfigFile) validateDelims() error {
+	var errs ValidationErrors
+	check := func(delims []string, path, location string) {
+		if len(delims) == 0 {
+			return
+		}
+		if len(delims) != 2 || delims[0] == "" || delims[1] == "" {
+			errs = append(errs, c.errorAt(path, "invalid delims %q for %s: expected a left and a right delimiter", delims, location))
+		}
 	}
-	if err := c.validateProjectGroupReferences(); err != nil {
-		return err
+
+	for _, groupName := range sortedKeys(c.TemplateGroups) {
+		for i, file := range c.TemplateGroups[groupName] {
+			check(file.Delims, fmt.Sprintf("templateGroups.%s[%d].delims", groupName, i), fmt.Sprintf("file %s in template group %s", file.Destination, groupName))
+		}
 	}
-	if err := c.validateURLSchemes(); err != nil {
-		return err
+	for i, project := range c.Projects {
+		check(project.Delims, fmt.Sprintf("projects[%d].delims", i), "project "+project.Name)
+		for j, groupRef := range project.Groups {
+			check(groupRef.Delims, fmt.Sprintf("projects[%d].groups[%d].delims", i, j), fmt.Sprintf("group %s in project %s", groupRef.GroupName, project.Name))
+		}
+		for j, file := range project.Files {
+			check(file.Delims, fmt.Sprintf("projects[%d].files[%d].delims", i, j), fmt.Sprintf("file %s in project %s", file.Destination, project.Name))
+		}
 	}
-	return nil
+	return errs.err()
+}
+
+// validateGroupSchemas checks that group schemas refer to existing template groups and are valid themselves.
+func (c *ConfigFile) validateGroupSchemas() error {
+	var errs ValidationErrors
+	for _, groupName := range sortedKeys(c.GroupSchemas) {
+		path := "groupSchemas." + groupName
+		if _, exists := c.TemplateGroups[groupName]; !exists {
+			errs = append(errs, c.errorAt(path, "schema declared for non-existent template group: %s", groupName))
+			continue
+		}
+		for _, err := range c.GroupSchemas[groupName].check() {
+			errs = append(errs, c.errorAt(path, "schema of template group %s: %v", groupName, err))
+		}
+	}
+	return errs.err()
+}
+
+// validateGroupValues checks the values projects pass to template groups against the group schemas.
+// The value scope of each project is returned by scopeOf, so that values are merged exactly as when
+// the groups are processed, including the overrides passed on the command line.
+func (c *ConfigFile) validateGroupValues(scopeOf func(Project) valueScope, strict bool) error {
+	var errs ValidationErrors
+	for i, projectCfg := range c.Projects {
+		if projectCfg.Abstract {
+			continue
+		}
+		project, err := c.FindProject(projectCfg.Name)
+		if err != nil {
+			// Inheritance problems are reported by validateProjectInheritance
+			continue
+		}
+		for _, groupRef := range project.Groups {
+			scope := scopeOf(project)
+			values := scope.groupValues(c.GroupSchemas[groupRef.GroupName].Defaults(), nil, groupRef.Values)
+			included, err := evaluateWhen(groupRef.When, values, firstDelims(groupRef.Delims, scope.Delims), strict)
+			if err != nil || !included {
+				// Errors in when expressions are reported when the project is processed
+				continue
+			}
+			path := c.groupRefPath(i, groupRef.GroupName)
+			errs = append(errs, c.validateGroupRefValues(path, project.Name, groupRef, groupRef.GroupName, nil, scope, strict, nil)...)
+		}
+	}
+	return errs.err()
+}
+
+// groupRefPath returns the path of a group reference in a project.
+// Group references inherited from other projects are located at the project.
+func (c *ConfigFile) groupRefPath(projectIndex int, groupName string) string {
+	for j, groupRef := range c.Projects[projectIndex].Groups {
+		if groupRef.GroupName == groupName {
+			return fmt.Sprintf("projects[%d].groups[%d]", projectIndex, j)
+		}
+	}
+	return fmt.Sprintf("projects[%d]", projectIndex)
+}
+
+// validateGroupRefValues checks the values a template group and the groups it includes receive against their schemas.
+// Values are merged in the same order as when the group is processed.
+func (c *ConfigFile) validateGroupRefValues(path, projectName string, groupRef TemplateGroupRef, groupName string, defaults map[string]any, scope valueScope, strict bool, chain []string) ValidationErrors {
+	if slices.Contains(chain, groupName) {
+		return nil
+	}
+	chain = append(slices.Clone(chain), groupName)
+
+	schema := c.GroupSchemas[groupName]
+	values := scope.groupValues(schema.Defaults(), defaults, groupRef.Values)
+
+	var errs ValidationErrors
+	for _, err := range schema.Validate(values) {
+		errs = append(errs, c.errorAt(path, "project %s: template group %s: %v", projectName, groupName, err))
+	}
+	for _, file := range c.TemplateGroups[groupName] {
+		if file.GroupName == "" {
+			continue
+		}
+		included, err := evaluateWhen(file.When, values, firstDelims(file.Delims, groupRef.Delims, scope.Delims), strict)
+		if err != nil || !included {
+			continue
+		}
+		errs = append(errs, c.validateGroupRefValues(path, projectName, groupRef, file.GroupName, mergeValues(file.Values, defaults), scope, strict, chain)...)
+	}
+	return errs
+}
+
+// validateProjectInheritance checks that projects extend existing projects without cycles.
+// A cycle is reported once, at the first project that is part of it.
+func (c *ConfigFile) validateProjectInheritance() error {
+	var errs ValidationErrors
+	reported := make(map[string]bool)
+	for i, project := range c.Projects {
+		if project.Extends == "" {
+			continue
+		}
+		path := fmt.Sprintf("projects[%d].extends", i)
+		if _, found := c.findProjectConfig(project.Extends); !found {
+			errs = append(errs, c.errorAt(path, "project %s extends non-existent project: %s", project.Name, project.Extends))
+			continue
+		}
+		if reported[project.Name] {
+			continue
+		}
+
+		// Follow the parents of the project to detect a cycle leading back to it
+		chain := []string{project.Name}
+		for parent := project.Extends; !slices.Contains(chain, parent); {
+			parentCfg, found := c.findProjectConfig(parent)
+			if !found {
+				break
+			}
+			chain = append(chain, parent)
+			parent = parentCfg.Extends
+			if parent == project.Name {
+				for _, name := range chain {
+					reported[name] = true
+				}
+				errs = append(errs, c.errorAt(path, "project inheritance cycle detected: %s", strings.Join(append(chain, parent), " -> ")))
+				break
+			}
+			if parent == "" {
+				break
+			}
+		}
+	}
+	return errs.err()
 }
 
 // validateDuplicateProjectNames checks for duplicate repository names.
 func (c *ConfigFile) validateDuplicateProjectNames() error {
+	var errs ValidationErrors
 	repoNames := make(map[string]bool)
-	for _, repo := range c.Projects {
+	for i, repo := range c.Projects {
 		if _, exists := repoNames[repo.Name]; exists {
-			return fmt.Errorf("duplicate repository name: %s", repo.Name)
+			errs = append(errs, c.errorAt(fmt.Sprintf("projects[%d].name", i), "duplicate repository name: %s", repo.Name))
 		}
 		repoNames[repo.Name] = true
 	}
-	return nil
+	return errs.err()
+}
+
+// validateDuplicateProjectOutputs checks that no two projects are rendered to the same output directory.
+func (c *ConfigFile) validateDuplicateProjectOutputs() error {
+	var errs ValidationErrors
+	outputs := make(map[string]string)
+	for i, project := range c.Projects {
+		if project.Output == "" {
+			continue
+		}
+		output := filepath.Clean(project.Output)
+		if other, exists := outputs[output]; exists {
+			errs = append(errs, c.errorAt(fmt.Sprintf("projects[%d].output", i), "projects %s and %s share the output directory: %s", other, project.Name, project.Output))
+			continue
+		}
+		outputs[output] = project.Name
+	}
+	return errs.err()
 }
 
 // validateDuplicateTemplateGroups checks for duplicate template groups.
 func (c *ConfigFile) validateDuplicateTemplateGroups() error {
+	var errs ValidationErrors
 	groupNames := make(map[string]bool)
-	for groupName := range c.TemplateGroups {
+	for _, groupName := range sortedKeys(c.TemplateGroups) {
 		if _, exists := groupNames[groupName]; exists {
-			return fmt.Errorf("duplicate template group: %s", groupName)
+			errs = append(errs, c.errorAt("templateGroups."+groupName, "duplicate template group: %s", groupName))
 		}
 		groupNames[groupName] = true
 	}
-	return nil
+	return errs.err()
+}
+
+// declaredFile is a file structure together with where it is declared in the configuration.
+type declaredFile struct {
+	File FileStructure
+	// Path locates the file structure in the configuration file, e.g. "projects[0].files[1]".
+	Path string
+	// Location describes where the file structure is declared, e.g. "template group common".
+	Location string
+	// InGroup reports whether the file structure is declared in a template group.
+	InGroup bool
+}
+
+// declaredFiles returns every file structure of the configuration, both in template groups and directly in projects.
+func (c *ConfigFile) declaredFiles() []declaredFile {
+	var files []declaredFile
+	for _, groupName := range sortedKeys(c.TemplateGroups) {
+		for i, file := range c.TemplateGroups[groupName] {
+			files = append(files, declaredFile{
+				File:     file,
+				Path:     fmt.Sprintf("templateGroups.%s[%d]", groupName, i),
+				Location: "template group " + groupName,
+				InGroup:  true,
+			})
+		}
+	}
+	for i, project := range c.Projects {
+		for j, file := range project.Files {
+			files = append(files, declaredFile{
+				File:     file,
+				Path:     fmt.Sprintf("projects[%d].files[%d]", i, j),
+				Location: "project " + project.Name,
+			})
+		}
+	}
+	return files
 }
 
-// validateFileStructures checks for conflicts in file structures.
+// validateFileStructures checks every file structure for conflicts and missing sources.
 func (c *ConfigFile) validateFileStructures() error {
-	for _, files := range c.TemplateGroups {
-		for _, file := range files {
-			if file.Source != "" && file.Content != "" {
-				return fmt.Errorf("both SourceFile and Content set for file: %s", file.Destination)
-			}
-			if file.Source != "" {
-				if _, err := os.Stat(file.Source); os.IsNotExist(err) {
-					return fmt.Errorf("template file or directory not found: %s", file.Source)
-				}
-			}
+	var errs ValidationErrors
+	for _, declared := range c.declaredFiles() {
+		errs = append(errs, c.validateFileStructure(declared)...)
+	}
+	return errs.err()
+}
+
+// validateFileStructure checks a single file structure.
+func (c *ConfigFile) validateFileStructure(declared declaredFile) ValidationErrors {
+	file := declared.File
+	if file.GroupName != "" {
+		if !declared.InGroup {
+			return ValidationErrors{c.errorAt(declared.Path+".groupName", "%s must not include group %s in its files, reference it in groups instead", declared.Location, file.GroupName)}
 		}
+		if file.Destination != "" || file.Source != "" || file.SourceURL != "" || file.Content != "" || file.ForEach != "" {
+			return ValidationErrors{c.errorAt(declared.Path, "%s includes group %s and must not define a destination, source, sourceUrl, content or forEach", declared.Location, file.GroupName)}
+		}
+		return nil
 	}
-	return nil
+
+	var errs ValidationErrors
+	if file.Destination == "" {
+		errs = append(errs, c.errorAt(declared.Path, "file in %s must define a destination", declared.Location))
+	}
+
+	var sources []string
+	for _, source := range []struct{ key, value string }{{"content", file.Content}, {"source", file.Source}, {"sourceUrl", file.SourceURL}} {
+		if source.value != "" {
+			sources = append(sources, source.key)
+		}
+	}
+	switch {
+	case len(sources) == 0:
+		errs = append(errs, c.errorAt(declared.Path, "file %s in %s must define one of content, source or sourceUrl", file.Destination, declared.Location))
+	case len(sources) > 1:
+		errs = append(errs, c.errorAt(declared.Path, "file %s in %s must define only one of content, source or sourceUrl, got %s", file.Destination, declared.Location, strings.Join(sources, " and ")))
+	}
+
+	if file.Source != "" {
+		if _, err := os.Stat(file.Source); os.IsNotExist(err) {
+			errs = append(errs, c.errorAt(declared.Path+".source", "template file or directory not found: %s", file.Source))
+		}
+	}
+	for i, pattern := range file.Raw {
+		if _, err := path.Match(pattern, ""); err != nil {
+			errs = append(errs, c.errorAt(fmt.Sprintf("%s.raw[%d]", declared.Path, i), "invalid raw pattern %q for file %s: %v", pattern, file.Destination, err))
+		}
+	}
+	return errs
 }
 
-// validateProjectGroupReferences checks if repositories refer to valid groups.
+// validateProjectGroupReferences checks if repositories and template groups refer to valid groups
+// and that template groups do not include each other in a cycle.
 func (c *ConfigFile) validateProjectGroupReferences() error {
-	for _, repo := range c.Projects {
-		for _, groupRef := range repo.Groups {
+	var errs ValidationErrors
+	for i, repo := range c.Projects {
+		for j, groupRef := range repo.Groups {
 			if _, exists := c.TemplateGroups[groupRef.GroupName]; !exists {
-				return fmt.Errorf("repository %s refers to non-existent group: %s", repo.Name, groupRef.GroupName)
+				errs = append(errs, c.errorAt(fmt.Sprintf("projects[%d].groups[%d].groupName", i, j), "repository %s refers to non-existent group: %s", repo.Name, groupRef.GroupName))
 			}
 		}
 	}
-	return nil
+
+	// Groups are checked in sorted order to report problems deterministically
+	checked := make(map[string]bool)
+	for _, groupName := range sortedKeys(c.TemplateGroups) {
+		errs = append(errs, c.validateGroupIncludes(groupName, nil, checked)...)
+	}
+	return errs.err()
 }
 
-// validateURLSchemes checks the validity of URLs in file structures.
+// validateGroupIncludes checks the groups included by a template group recursively.
+// The chain holds the groups currently being resolved and is reported if a cycle is found.
+// Groups that have been checked are skipped, so that every problem is reported once.
+func (c *ConfigFile) validateGroupIncludes(groupName string, chain []string, checked map[string]bool) ValidationErrors {
+	if checked[groupName] {
+		return nil
+	}
+	checked[groupName] = true
+	chain = append(slices.Clone(chain), groupName)
+
+	var errs ValidationErrors
+	for i, file := range c.TemplateGroups[groupName] {
+		if file.GroupName == "" {
+			continue
+		}
+		includePath := fmt.Sprintf("templateGroups.%s[%d].groupName", groupName, i)
+		if _, exists := c.TemplateGroups[file.GroupName]; !exists {
+			errs = append(errs, c.errorAt(includePath, "template group %s includes non-existent group: %s", groupName, file.GroupName))
+			continue
+		}
+		if slices.Contains(chain, file.GroupName) {
+			errs = append(errs, c.errorAt(includePath, "template group cycle detected: %s", strings.Join(append(chain, file.GroupName), " -> ")))
+			continue
+		}
+		errs = append(errs, c.validateGroupIncludes(file.GroupName, chain, checked)...)
+	}
+	return errs
+}
+
+// validateURLSchemes checks the validity of URLs in all file structures.
 func (c *ConfigFile) validateURLSchemes() error {
-	for _, files := range c.TemplateGroups {
-		for _, file := range files {
-			if file.SourceURL != "" {
-				if _, err := url.ParseRequestURI(file.SourceURL); err != nil {
-					return fmt.Errorf("invalid SourceURL: %s", file.SourceURL)
-				}
+	var errs ValidationErrors
+	for _, declared := range c.declaredFiles() {
+		if declared.File.SourceURL != "" {
+			if _, err := url.ParseRequestURI(declared.File.SourceURL); err != nil {
+				errs = append(errs, c.errorAt(declared.Path+".sourceUrl", "invalid SourceURL: %s", declared.File.SourceURL))
 			}
 		}
 	}
-	return nil
+	return errs.err()
 }
 
+// FindProject returns the project with the given name, including everything it inherits.
 func (c *ConfigFile) FindProject(project string) (Project, error) {
 	projectCfg, found := c.findProjectConfig(project)
 	if !found {
 		return Project{}, fmt.Errorf("project %s not found in configuration", project)
 	}
-	return Project(projectCfg), nil
+	if projectCfg.Abstract {
+		return Project{}, fmt.Errorf("project %s is abstract and cannot be rendered", project)
+	}
+
+	projectCfg, err := c.resolveProject(projectCfg, nil)
+	if err != nil {
+		return Project{}, err
+	}
+
+	return Project{
+		Name:   projectCfg.Name,
+		Files:  projectCfg.Files,
+		Groups: projectCfg.Groups,
+		Values: projectCfg.Values,
+		Output: projectCfg.Output,
+		Delims: projectCfg.Delims,
+	}, nil
+}
+
+// resolveProject merges a project configuration with the projects it extends.
+// The chain holds the projects currently being resolved and is reported if a cycle is found.
+func (c *ConfigFile) resolveProject(project ProjectConfig, chain []string) (ProjectConfig, error) {
+	if project.Extends == "" {
+		return project, nil
+	}
+	if slices.Contains(chain, project.Name) {
+		return ProjectConfig{}, fmt.Errorf("project inheritance cycle detected: %s", strings.Join(append(chain, project.Name), " -> "))
+	}
+
+	parentCfg, found := c.findProjectConfig(project.Extends)
+	if !found {
+		return ProjectConfig{}, fmt.Errorf("project %s extends non-existent project: %s", project.Name, project.Extends)
+	}
+
+	parent, err := c.resolveProject(parentCfg, append(slices.Clone(chain), project.Name))
+	if err != nil {
+		return ProjectConfig{}, err
+	}
+	return mergeProjectConfigs(parent, project), nil
+}
+
+// mergeProjectConfigs merges a child project into the resolved parent project.
+// Files are matched by destination and groups by name; project values and values of matching entries are
+// deep-merged with the child's values taking precedence. Name, output and abstract are not inherited.
+func mergeProjectConfigs(parent, child ProjectConfig) ProjectConfig {
+	merged := ProjectConfig{
+		Name:     child.Name,
+		Output:   child.Output,
+		Abstract: child.Abstract,
+		Delims:   child.Delims,
+	}
+	if len(merged.Delims) == 0 {
+		merged.Delims = parent.Delims
+	}
+	if parent.Values != nil || child.Values != nil {
+		merged.Values = mergeValues(parent.Values, child.Values)
+	}
+
+	childFiles := make(map[string]FileStructure)
+	for _, file := range child.Files {
+		childFiles[file.Destination] = file
+	}
+	for _, file := range parent.Files {
+		if override, exists := childFiles[file.Destination]; exists {
+			override.Values = mergeValues(file.Values, override.Values)
+			merged.Files = append(merged.Files, override)
+			delete(childFiles, file.Destination)
+			continue
+		}
+		merged.Files = append(merged.Files, file)
+	}
+	for _, file := range child.Files {
+		if _, pending := childFiles[file.Destination]; pending {
+			merged.Files = append(merged.Files, file)
+		}
+	}
+
+	childGroups := make(map[string]TemplateGroupRef)
+	for _, groupRef := range child.Groups {
+		childGroups[groupRef.GroupName] = groupRef
+	}
+	for _, groupRef := range parent.Groups {
+		if override, exists := childGroups[groupRef.GroupName]; exists {
+			override.Values = mergeValues(groupRef.Values, override.Values)
+			merged.Groups = append(merged.Groups, override)
+			delete(childGroups, groupRef.GroupName)
+			continue
+		}
+		merged.Groups = append(merged.Groups, groupRef)
+	}
+	for _, groupRef := range child.Groups {
+		if _, pending := childGroups[groupRef.GroupName]; pending {
+			merged.Groups = append(merged.Groups, groupRef)
+		}
+	}
+
+	return merged
+}
+
+// regexSelectorPrefix marks a project selector as a regular expression.
+const regexSelectorPrefix = "re:"
+
+// SelectProjects returns the projects matching any of the selectors, in configuration order.
+// A selector is either an exact project name, a glob pattern such as "example/*" or a
+// regular expression prefixed with "re:". If all is set, every project is returned.
+// Abstract projects are never selected.
+func (c *ConfigFile) SelectProjects(selectors []string, all bool) ([]Project, error) {
+	if !all && len(selectors) == 0 {
+		return nil, fmt.Errorf("no project selected: pass a project name, a selector or --all")
+	}
+
+	matchers := make([]func(string) bool, 0, len(selectors))
+	for _, selector := range selectors {
+		matcher, err := projectMatcher(selector)
+		if err != nil {
+			return nil, err
+		}
+
+		matched := false
+		for _, project := range c.Projects {
+			if project.Abstract && project.Name == selector {
+				return nil, fmt.Errorf("project %s is abstract and cannot be rendered", project.Name)
+			}
+			if !project.Abstract && matcher(project.Name) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return nil, fmt.Errorf("no project in configuration matches %s", selector)
+		}
+		matchers = append(matchers, matcher)
+	}
+
+	var projects []Project
+	for _, projectCfg := range c.Projects {
+		// Abstract projects only serve as parents
+		if projectCfg.Abstract {
+			continue
+		}
+
+		selected := all
+		for _, matcher := range matchers {
+			if matcher(projectCfg.Name) {
+				selected = true
+				break
+			}
+		}
+		if !selected {
+			continue
+		}
+
+		project, err := c.FindProject(projectCfg.Name)
+		if err != nil {
+			return nil, err
+		}
+		projects = append(projects, project)
+	}
+	return projects, nil
+}
+
+// projectMatcher returns a function that matches project names against a selector.
+func projectMatcher(selector string) (func(string) bool, error) {
+	if expr, isRegex := strings.CutPrefix(selector, regexSelectorPrefix); isRegex {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid project selector %s: %w", selector, err)
+		}
+		return re.MatchString, nil
+	}
+
+	if strings.ContainsAny(selector, "*?[") {
+		if _, err := path.Match(selector, ""); err != nil {
+			return nil, fmt.Errorf("invalid project selector %s: %w", selector, err)
+		}
+		return func(name string) bool {
+			matched, _ := path.Match(selector, name)
+			return matched
+		}, nil
+	}
+
+	return func(name string) bool {
+		return name == selector
+	}, nil
 }
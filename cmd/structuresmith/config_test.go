@@ -1,6 +1,8 @@
 package main
 
 import (
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 
@@ -217,6 +219,59 @@ func TestValidateProjectGroupReferences(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "Valid Group Include",
+			config: ConfigFile{
+				Projects: []ProjectConfig{
+					{Name: "project1", Groups: []TemplateGroupRef{{GroupName: "group2"}}},
+				},
+				TemplateGroups: map[string][]FileStructure{
+					"group1": {},
+					"group2": {{GroupName: "group1"}},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Group Includes Non-Existent Group",
+			config: ConfigFile{
+				TemplateGroups: map[string][]FileStructure{
+					"group1": {{GroupName: "nonExistentGroup"}},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Group Includes Itself",
+			config: ConfigFile{
+				TemplateGroups: map[string][]FileStructure{
+					"group1": {{GroupName: "group1"}},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Group Include Cycle",
+			config: ConfigFile{
+				TemplateGroups: map[string][]FileStructure{
+					"group1": {{GroupName: "group2"}},
+					"group2": {{GroupName: "group3"}},
+					"group3": {{GroupName: "group1"}},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Group Included Twice Without Cycle",
+			config: ConfigFile{
+				TemplateGroups: map[string][]FileStructure{
+					"common": {},
+					"group1": {{GroupName: "common"}},
+					"group2": {{GroupName: "common"}, {GroupName: "group1"}},
+				},
+			},
+			wantErr: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -318,6 +373,13 @@ func TestValidateURLSchemes(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "Invalid URL In Project File",
+			config: ConfigFile{
+				Projects: []ProjectConfig{{Name: "repo1", Files: []FileStructure{{Destination: "a", SourceURL: "invalid-url"}}}},
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -634,3 +696,321 @@ overwrite: false
 		})
 	}
 }
+
+func TestValidateFileStructures(t *testing.T) {
+	tmpDir := t.TempDir()
+	existing := filepath.Join(tmpDir, "README.md.tmpl")
+	if err := os.WriteFile(existing, []byte("readme"), 0o644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		config  ConfigFile
+		wantErr string
+	}{
+		{
+			name: "Valid Files",
+			config: ConfigFile{
+				TemplateGroups: map[string][]FileStructure{"group1": {{Destination: "README.md", Source: existing}, {GroupName: "group2"}}},
+				Projects:       []ProjectConfig{{Name: "repo1", Files: []FileStructure{{Destination: "a", Content: "a"}, {Destination: "b", SourceURL: "https://example.com"}}}},
+			},
+		},
+		{
+			name:    "Content And Source In Group File",
+			config:  ConfigFile{TemplateGroups: map[string][]FileStructure{"group1": {{Destination: "a", Content: "a", Source: existing}}}},
+			wantErr: "file a in template group group1 must define only one of content, source or sourceUrl, got content and source",
+		},
+		{
+			name:    "Content And SourceURL In Project File",
+			config:  ConfigFile{Projects: []ProjectConfig{{Name: "repo1", Files: []FileStructure{{Destination: "a", Content: "a", SourceURL: "https://example.com"}}}}},
+			wantErr: "file a in project repo1 must define only one of content, source or sourceUrl, got content and sourceUrl",
+		},
+		{
+			name:    "No Source In Project File",
+			config:  ConfigFile{Projects: []ProjectConfig{{Name: "repo1", Files: []FileStructure{{Destination: "a"}}}}},
+			wantErr: "file a in project repo1 must define one of content, source or sourceUrl",
+		},
+		{
+			name:    "Missing Source In Project File",
+			config:  ConfigFile{Projects: []ProjectConfig{{Name: "repo1", Files: []FileStructure{{Destination: "a", Source: filepath.Join(tmpDir, "missing.tmpl")}}}}},
+			wantErr: "template file or directory not found: " + filepath.Join(tmpDir, "missing.tmpl"),
+		},
+		{
+			name:    "No Destination",
+			config:  ConfigFile{Projects: []ProjectConfig{{Name: "repo1", Files: []FileStructure{{Content: "a"}}}}},
+			wantErr: "file in project repo1 must define a destination",
+		},
+		{
+			name:    "Invalid Raw Pattern In Project File",
+			config:  ConfigFile{Projects: []ProjectConfig{{Name: "repo1", Files: []FileStructure{{Destination: "a", Source: existing, Raw: []string{"["}}}}}},
+			wantErr: `invalid raw pattern "[" for file a: syntax error in pattern`,
+		},
+		{
+			name:    "Group Include In Project File",
+			config:  ConfigFile{Projects: []ProjectConfig{{Name: "repo1", Files: []FileStructure{{GroupName: "group1"}}}}},
+			wantErr: "project repo1 must not include group group1 in its files, reference it in groups instead",
+		},
+		{
+			name:    "Group Include With Destination",
+			config:  ConfigFile{TemplateGroups: map[string][]FileStructure{"group1": {{GroupName: "group2", Destination: "a"}}}},
+			wantErr: "template group group1 includes group group2 and must not define a destination, source, sourceUrl, content or forEach",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.validateFileStructures()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validateFileStructures() unexpected error = %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("validateFileStructures() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSelectProjects(t *testing.T) {
+	config := ConfigFile{
+		Projects: []ProjectConfig{
+			{Name: "example/repo1"},
+			{Name: "example/repo2"},
+			{Name: "other/repo1"},
+			{Name: "standalone"},
+			{Name: "example/base", Abstract: true},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		selectors []string
+		all       bool
+		want      []string
+		wantError bool
+	}{
+		{name: "Exact Name", selectors: []string{"standalone"}, want: []string{"standalone"}},
+		{name: "Glob Pattern", selectors: []string{"example/*"}, want: []string{"example/repo1", "example/repo2"}},
+		{name: "Glob Pattern Across Owners", selectors: []string{"*/repo1"}, want: []string{"example/repo1", "other/repo1"}},
+		{name: "Regular Expression", selectors: []string{"re:repo[12]$"}, want: []string{"example/repo1", "example/repo2", "other/repo1"}},
+		{name: "Multiple Selectors Keep Config Order", selectors: []string{"standalone", "example/repo1"}, want: []string{"example/repo1", "standalone"}},
+		{name: "Overlapping Selectors Are Deduplicated", selectors: []string{"example/*", "example/repo1"}, want: []string{"example/repo1", "example/repo2"}},
+		{name: "All Projects", all: true, want: []string{"example/repo1", "example/repo2", "other/repo1", "standalone"}},
+		{name: "No Selector", wantError: true},
+		{name: "Unknown Project", selectors: []string{"unknown"}, wantError: true},
+		{name: "Glob Without Matches", selectors: []string{"unknown/*"}, wantError: true},
+		{name: "Invalid Regular Expression", selectors: []string{"re:("}, wantError: true},
+		{name: "Invalid Glob Pattern", selectors: []string{"example/["}, wantError: true},
+		{name: "Abstract Project By Name", selectors: []string{"example/base"}, wantError: true},
+		{name: "Abstract Project Only Match", selectors: []string{"re:base$"}, wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := config.SelectProjects(tt.selectors, tt.all)
+			if (err != nil) != tt.wantError {
+				t.Fatalf("SelectProjects() error = %v, wantError %v", err, tt.wantError)
+			}
+
+			var names []string
+			for _, p := range got {
+				names = append(names, p.Name)
+			}
+			if !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("SelectProjects() = %v, want %v", names, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateDuplicateProjectOutputs(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  ConfigFile
+		wantErr bool
+	}{
+		{name: "No Outputs", config: ConfigFile{Projects: []ProjectConfig{{Name: "repo1"}, {Name: "repo2"}}}, wantErr: false},
+		{name: "Distinct Outputs", config: ConfigFile{Projects: []ProjectConfig{{Name: "repo1", Output: "a"}, {Name: "repo2", Output: "b"}}}, wantErr: false},
+		{name: "Duplicate Outputs", config: ConfigFile{Projects: []ProjectConfig{{Name: "repo1", Output: "a"}, {Name: "repo2", Output: "a"}}}, wantErr: true},
+		{name: "Duplicate Outputs After Cleaning", config: ConfigFile{Projects: []ProjectConfig{{Name: "repo1", Output: "a/b"}, {Name: "repo2", Output: "a/./b/"}}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.validateDuplicateProjectOutputs()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateDuplicateProjectOutputs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateGroupIncludesReportsChain(t *testing.T) {
+	config := ConfigFile{
+		TemplateGroups: map[string][]FileStructure{
+			"a": {{GroupName: "b"}},
+			"b": {{GroupName: "c"}},
+			"c": {{GroupName: "a"}},
+		},
+	}
+
+	err := config.validateProjectGroupReferences()
+	if err == nil {
+		t.Fatal("validateProjectGroupReferences() expected cycle error")
+	}
+	want := "template group cycle detected: a -> b -> c -> a"
+	if err.Error() != want {
+		t.Errorf("validateProjectGroupReferences() error = %q, want %q", err.Error(), want)
+	}
+}
+
+func TestFindProjectWithExtends(t *testing.T) {
+	config := ConfigFile{
+		Projects: []ProjectConfig{
+			{
+				Name:     "base",
+				Abstract: true,
+				Files: []FileStructure{
+					{Destination: "README.md", Content: "readme", Values: map[string]any{"Author": "base", "Year": 2024}},
+					{Destination: "LICENSE", Content: "license"},
+				},
+				Groups: []TemplateGroupRef{
+					{GroupName: "common", Values: map[string]any{"Owner": "base", "Nested": map[string]any{"a": 1, "b": 2}}},
+				},
+			},
+			{
+				Name:    "service",
+				Extends: "base",
+				Output:  "service",
+				Files: []FileStructure{
+					{Destination: "README.md", Content: "service readme", Values: map[string]any{"Author": "service"}},
+					{Destination: "main.go", Content: "package main"},
+				},
+				Groups: []TemplateGroupRef{
+					{GroupName: "common", Values: map[string]any{"Nested": map[string]any{"b": 3}}},
+					{GroupName: "go"},
+				},
+			},
+			{Name: "loop1", Extends: "loop2"},
+			{Name: "loop2", Extends: "loop1"},
+			{Name: "orphan", Extends: "unknown"},
+		},
+	}
+
+	t.Run("Inherits Files And Groups", func(t *testing.T) {
+		got, err := config.FindProject("service")
+		if err != nil {
+			t.Fatalf("FindProject() error = %v", err)
+		}
+
+		want := Project{
+			Name:   "service",
+			Output: "service",
+			Files: []FileStructure{
+				{Destination: "README.md", Content: "service readme", Values: map[string]any{"Author": "service", "Year": 2024}},
+				{Destination: "LICENSE", Content: "license"},
+				{Destination: "main.go", Content: "package main"},
+			},
+			Groups: []TemplateGroupRef{
+				{GroupName: "common", Values: map[string]any{"Owner": "base", "Nested": map[string]any{"a": 1, "b": 3}}},
+				{GroupName: "go"},
+			},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("FindProject() = %+v, want %+v", got, want)
+		}
+	})
+
+	for _, name := range []string{"base", "loop1", "orphan"} {
+		t.Run("Rejects "+name, func(t *testing.T) {
+			if _, err := config.FindProject(name); err == nil {
+				t.Errorf("FindProject(%q) expected error, got nil", name)
+			}
+		})
+	}
+}
+
+func TestValidateProjectInheritance(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  ConfigFile
+		wantErr string
+	}{
+		{
+			name:   "Valid Inheritance",
+			config: ConfigFile{Projects: []ProjectConfig{{Name: "base", Abstract: true}, {Name: "child", Extends: "base"}, {Name: "grandchild", Extends: "child"}}},
+		},
+		{
+			name:    "Unknown Parent",
+			config:  ConfigFile{Projects: []ProjectConfig{{Name: "child", Extends: "base"}}},
+			wantErr: "project child extends non-existent project: base",
+		},
+		{
+			name:    "Inheritance Cycle",
+			config:  ConfigFile{Projects: []ProjectConfig{{Name: "a", Extends: "b"}, {Name: "b", Extends: "c"}, {Name: "c", Extends: "a"}}},
+			wantErr: "project inheritance cycle detected: a -> b -> c -> a",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.validateProjectInheritance()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validateProjectInheritance() unexpected error = %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("validateProjectInheritance() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateDelims(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  ConfigFile
+		wantErr bool
+	}{
+		{
+			name: "Valid Delims",
+			config: ConfigFile{
+				TemplateGroups: map[string][]FileStructure{"group1": {{Destination: "a", Content: "a", Delims: []string{"[[", "]]"}}}},
+				Projects:       []ProjectConfig{{Name: "repo1", Delims: []string{"<<", ">>"}, Groups: []TemplateGroupRef{{GroupName: "group1", Delims: []string{"((", "))"}}}}},
+			},
+		},
+		{
+			name:    "Single Delimiter In Group File",
+			config:  ConfigFile{TemplateGroups: map[string][]FileStructure{"group1": {{Destination: "a", Content: "a", Delims: []string{"[["}}}}},
+			wantErr: true,
+		},
+		{
+			name:    "Empty Delimiter In Project",
+			config:  ConfigFile{Projects: []ProjectConfig{{Name: "repo1", Delims: []string{"[[", ""}}}},
+			wantErr: true,
+		},
+		{
+			name:    "Too Many Delimiters In Group Reference",
+			config:  ConfigFile{Projects: []ProjectConfig{{Name: "repo1", Groups: []TemplateGroupRef{{GroupName: "group1", Delims: []string{"[[", "]]", "!!"}}}}}},
+			wantErr: true,
+		},
+		{
+			name:    "Invalid Delims In Project File",
+			config:  ConfigFile{Projects: []ProjectConfig{{Name: "repo1", Files: []FileStructure{{Destination: "a", Content: "a", Delims: []string{"", "]]"}}}}}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.config.validateDelims(); (err != nil) != tt.wantErr {
+				t.Errorf("validateDelims() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
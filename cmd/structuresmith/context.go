@@ -0,0 +1,81 @@
+package main
+
+import (
+	"path/filepath"
+	"time"
+)
+
+// SmithValuesKey is the reserved key under which the built-in template context is exposed to templates.
+const SmithValuesKey = "Smith"
+
+// SmithContext is the built-in template context available as .Smith in every template.
+type SmithContext struct {
+	Project SmithProject
+	File    SmithFile
+	Config  SmithConfig
+	Version string
+	Now     time.Time
+}
+
+// SmithProject describes the project a file is rendered for.
+type SmithProject struct {
+	Name      string
+	OutputDir string
+}
+
+// SmithFile describes the file that is being rendered.
+type SmithFile struct {
+	Destination string
+	Source      string
+	SourceURL   string
+}
+
+// SmithConfig describes the configuration file the project was loaded from.
+type SmithConfig struct {
+	Path string
+}
+
+// smithContext returns the built-in template context of a project, without information about a file.
+func (app *Structuresmith) smithContext(p Project) SmithContext {
+	return SmithContext{
+		Project: SmithProject{Name: p.Name, OutputDir: app.configRelativePath(app.OutputDir)},
+		Config:  SmithConfig{Path: app.configRelativePath(app.ConfigFile)},
+		Version: Version,
+		Now:     StartTime,
+	}
+}
+
+// withSmithContext returns the values of a file extended by the built-in template context.
+// A user-defined value with the reserved key is replaced.
+func (app *Structuresmith) withSmithContext(p Project, file FileStructure) map[string]any {
+	smith := app.smithContext(p)
+	smith.File = SmithFile{
+		Destination: file.Destination,
+		Source:      app.templateRelativePath(file.Source),
+		SourceURL:   file.SourceURL,
+	}
+	return mergeValues(file.Values, map[string]any{SmithValuesKey: smith})
+}
+
+// configRelativePath returns a path relative to the directory of the configuration file,
+// so that rendered files do not depend on the absolute location of the checkout.
+func (app *Structuresmith) configRelativePath(path string) string {
+	if path == "" || app.ConfigFile == "" {
+		return path
+	}
+	if rel, err := filepath.Rel(filepath.Dir(app.ConfigFile), path); err == nil {
+		return filepath.ToSlash(rel)
+	}
+	return path
+}
+
+// templateRelativePath returns a source path relative to the templates directory, if it is located inside of it.
+func (app *Structuresmith) templateRelativePath(source string) string {
+	if source == "" || app.TemplatesDir == "" {
+		return source
+	}
+	if rel, err := filepath.Rel(app.TemplatesDir, source); err == nil && filepath.IsLocal(rel) {
+		return filepath.ToSlash(rel)
+	}
+	return source
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWithSmithContext(t *testing.T) {
+	app := &Structuresmith{ConfigFile: "/repo/anvil.yml", OutputDir: "/repo/out/project1", TemplatesDir: "/repo/templates"}
+	project := Project{Name: "example/project1"}
+	file := FileStructure{
+		Destination: "README.md",
+		Source:      "/repo/templates/docs/readme.tmpl",
+		Values:      map[string]any{"Author": "Alice", SmithValuesKey: "overwritten"},
+	}
+
+	values := app.withSmithContext(project, file)
+
+	smith, ok := values[SmithValuesKey].(SmithContext)
+	if !ok {
+		t.Fatalf("values[%q] = %T, want SmithContext", SmithValuesKey, values[SmithValuesKey])
+	}
+	if smith.Project.Name != "example/project1" {
+		t.Errorf("Project.Name = %q, want %q", smith.Project.Name, "example/project1")
+	}
+	if smith.Project.OutputDir != "out/project1" {
+		t.Errorf("Project.OutputDir = %q, want %q", smith.Project.OutputDir, "out/project1")
+	}
+	if smith.File.Destination != "README.md" {
+		t.Errorf("File.Destination = %q, want %q", smith.File.Destination, "README.md")
+	}
+	if smith.File.Source != "docs/readme.tmpl" {
+		t.Errorf("File.Source = %q, want %q", smith.File.Source, "docs/readme.tmpl")
+	}
+	if smith.Config.Path != "anvil.yml" {
+		t.Errorf("Config.Path = %q, want %q", smith.Config.Path, "anvil.yml")
+	}
+	if !smith.Now.Equal(StartTime) {
+		t.Errorf("Now = %v, want %v", smith.Now, StartTime)
+	}
+	if values["Author"] != "Alice" {
+		t.Errorf("Author = %v, want user values to be kept", values["Author"])
+	}
+	if _, exists := file.Values[SmithValuesKey].(SmithContext); exists {
+		t.Error("withSmithContext() modified the values of the file")
+	}
+}
+
+func TestRenderContentWithSmithContext(t *testing.T) {
+	app := &Structuresmith{ConfigFile: "anvil.yml", TemplatesDir: "templates"}
+	file := FileStructure{
+		Destination: "CODEOWNERS",
+		Content:     "# {{ .Smith.Project.Name }} ({{ .Smith.File.Destination }})",
+	}
+	file.Values = app.withSmithContext(Project{Name: "example/repo"}, file)
+
+	got, err := app.renderContent(file)
+	if err != nil {
+		t.Fatalf("renderContent() error = %v", err)
+	}
+	if want := "# example/repo (CODEOWNERS)"; string(got) != want {
+		t.Errorf("renderContent() = %q, want %q", got, want)
+	}
+}
+
+func TestConfigRelativePath(t *testing.T) {
+	tests := []struct {
+		name       string
+		configFile string
+		path       string
+		want       string
+	}{
+		{name: "Config File", configFile: "/repo/anvil.yml", path: "/repo/anvil.yml", want: "anvil.yml"},
+		{name: "Inside Config Directory", configFile: "/repo/anvil.yml", path: "/repo/out/project1", want: "out/project1"},
+		{name: "Outside Config Directory", configFile: "/repo/anvil.yml", path: "/repos/project1", want: "../repos/project1"},
+		{name: "Relative Paths", configFile: "anvil.yml", path: "out", want: "out"},
+		{name: "Empty Path", configFile: "/repo/anvil.yml", path: "", want: ""},
+		{name: "Mixed Paths", configFile: "anvil.yml", path: "/repo/out", want: "/repo/out"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := &Structuresmith{ConfigFile: tt.configFile}
+			if got := app.configRelativePath(tt.path); got != tt.want {
+				t.Errorf("configRelativePath(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcessProjectWhenWithSmithContext(t *testing.T) {
+	cfg := ConfigFile{TemplateGroups: map[string][]FileStructure{
+		"docs": {
+			{Destination: "README.md", Content: "x", When: `{{ eq .Smith.Project.Name "project1" }}`},
+			{Destination: "CONTRIBUTING.md", Content: "x", When: `{{ eq .Smith.Config.Path "other.yml" }}`},
+		},
+	}}
+	project := Project{
+		Name:   "project1",
+		Files:  []FileStructure{{Destination: "LICENSE", Content: "x", When: `{{ eq .Smith.Project.Name "project1" }}`}},
+		Groups: []TemplateGroupRef{{GroupName: "docs", When: `{{ eq .Smith.Project.Name "project1" }}`}},
+	}
+	app := &Structuresmith{ConfigFile: "/repo/anvil.yml", Strict: true}
+
+	files, err := app.processProject(project, cfg)
+	if err != nil {
+		t.Fatalf("processProject() error = %v", err)
+	}
+	var got []string
+	for _, file := range files {
+		got = append(got, file.Destination)
+	}
+	if want := []string{"LICENSE", "README.md"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("processProject() destinations = %v, want %v", got, want)
+	}
+}
+
+func TestProcessProjectForEachWithSmithContext(t *testing.T) {
+	project := Project{
+		Name: "project1",
+		Files: []FileStructure{{
+			Destination: "{{ .item }}.txt",
+			Content:     "x",
+			ForEach:     `list .Smith.Project.Name .Smith.Config.Path`,
+		}},
+	}
+	app := &Structuresmith{ConfigFile: "/repo/anvil.yml", Strict: true}
+
+	files, err := app.processProject(project, ConfigFile{})
+	if err != nil {
+		t.Fatalf("processProject() error = %v", err)
+	}
+	var got []string
+	for _, file := range files {
+		got = append(got, file.Destination)
+	}
+	if want := []string{"project1.txt", "anvil.yml.txt"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("processProject() destinations = %v, want %v", got, want)
+	}
+}
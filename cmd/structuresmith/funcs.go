@@ -0,0 +1,529 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// templateFuncs returns the functions available in every template.
+// Names and argument order follow the conventions of Helm and sprig, so that
+// the value a function is applied to can be piped in as the last argument.
+func templateFuncs() template.FuncMap {
+	return template.FuncMap{
+		// Strings
+		"upper":      strings.ToUpper,
+		"lower":      strings.ToLower,
+		"title":      title,
+		"trim":       strings.TrimSpace,
+		"trimAll":    func(cutset, s string) string { return strings.Trim(s, cutset) },
+		"trimPrefix": func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+		"trimSuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+		"replace":    func(old, replacement, s string) string { return strings.ReplaceAll(s, old, replacement) },
+		"contains":   func(substr, s string) bool { return strings.Contains(s, substr) },
+		"hasPrefix":  func(prefix, s string) bool { return strings.HasPrefix(s, prefix) },
+		"hasSuffix":  func(suffix, s string) bool { return strings.HasSuffix(s, suffix) },
+		"repeat":     func(count int, s string) string { return strings.Repeat(s, max(count, 0)) },
+		"trunc":      trunc,
+		"nospace":    func(s string) string { return strings.Join(strings.Fields(s), "") },
+		"quote":      quote(`"`),
+		"squote":     quote(`'`),
+		"indent":     indent,
+		"nindent":    func(spaces int, s string) string { return "\n" + indent(spaces, s) },
+		"camelcase":  camelcase,
+		"snakecase":  func(s string) string { return strings.Join(lowerWords(s), "_") },
+		"kebabcase":  func(s string) string { return strings.Join(lowerWords(s), "-") },
+		"splitList":  func(sep, s string) []string { return strings.Split(s, sep) },
+		"toString":   func(v any) string { return fmt.Sprint(v) },
+
+		// Lists
+		"list":      func(items ...any) []any { return items },
+		"join":      join,
+		"first":     first,
+		"last":      last,
+		"rest":      rest,
+		"append":    appendList,
+		"has":       has,
+		"uniq":      uniq,
+		"sortAlpha": sortAlpha,
+
+		// Dictionaries
+		"dict":   dict,
+		"get":    func(d map[string]any, key string) any { return d[key] },
+		"set":    func(d map[string]any, key string, value any) map[string]any { d[key] = value; return d },
+		"unset":  func(d map[string]any, key string) map[string]any { delete(d, key); return d },
+		"hasKey": func(d map[string]any, key string) bool { _, ok := d[key]; return ok },
+		"keys":   keys,
+		"merge":  merge,
+
+		// Defaults and flow control
+		"default":  defaultValue,
+		"empty":    empty,
+		"coalesce": coalesce,
+		"ternary":  func(whenTrue, whenFalse any, condition bool) any { return ternary(condition, whenTrue, whenFalse) },
+		"required": required,
+		"fail":     func(msg string) (string, error) { return "", errors.New(msg) },
+
+		// Encoding
+		"toJson":       toJSON,
+		"toPrettyJson": toPrettyJSON,
+		"fromJson":     fromJSON,
+		"toYaml":       toYAML,
+		"fromYaml":     fromYAML,
+		"b64enc":       func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) },
+		"b64dec":       b64dec,
+		"sha256sum":    func(s string) string { sum := sha256.Sum256([]byte(s)); return hex.EncodeToString(sum[:]) },
+
+		// Dates
+		"now":  time.Now,
+		"date": date,
+
+		// Math
+		"add": arithmetic(func(a, b int64) int64 { return a + b }),
+		"sub": arithmetic(func(a, b int64) int64 { return a - b }),
+		"mul": arithmetic(func(a, b int64) int64 { return a * b }),
+		"div": division(func(a, b int64) int64 { return a / b }),
+		"mod": division(func(a, b int64) int64 { return a % b }),
+		"atoi": func(s string) (int, error) {
+			return strconv.Atoi(strings.TrimSpace(s))
+		},
+	}
+}
+
+// title capitalizes the first letter of every word.
+func title(s string) string {
+	runes := []rune(s)
+	for i, r := range runes {
+		if i == 0 || !unicode.IsLetter(runes[i-1]) && !unicode.IsDigit(runes[i-1]) {
+			runes[i] = unicode.ToUpper(r)
+		}
+	}
+	return string(runes)
+}
+
+// trunc truncates a string to the given number of characters.
+// A negative length keeps the given number of characters from the end.
+func trunc(length int, s string) string {
+	runes := []rune(s)
+	switch {
+	case length >= 0 && len(runes) > length:
+		return string(runes[:length])
+	case length < 0 && len(runes) > -length:
+		return string(runes[len(runes)+length:])
+	default:
+		return s
+	}
+}
+
+// quote returns a function that wraps every argument in the given quote character.
+func quote(char string) func(values ...any) string {
+	return func(values ...any) string {
+		quoted := make([]string, 0, len(values))
+		for _, v := range values {
+			if v == nil {
+				continue
+			}
+			quoted = append(quoted, char+fmt.Sprint(v)+char)
+		}
+		return strings.Join(quoted, " ")
+	}
+}
+
+// indent prefixes every line of s with the given number of spaces.
+func indent(spaces int, s string) string {
+	pad := strings.Repeat(" ", max(spaces, 0))
+	return pad + strings.ReplaceAll(s, "\n", "\n"+pad)
+}
+
+// splitWords splits an identifier into words at separators and case changes,
+// e.g. "HTTPServer_name" becomes "HTTP", "Server" and "name".
+func splitWords(s string) []string {
+	var words []string
+	var current []rune
+	runes := []rune(s)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if len(current) > 0 {
+				words = append(words, string(current))
+				current = nil
+			}
+			continue
+		}
+		if len(current) > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || unicode.IsUpper(prev) && nextIsLower {
+				words = append(words, string(current))
+				current = nil
+			}
+		}
+		current = append(current, r)
+	}
+	if len(current) > 0 {
+		words = append(words, string(current))
+	}
+	return words
+}
+
+// lowerWords returns the words of an identifier in lower case.
+func lowerWords(s string) []string {
+	words := splitWords(s)
+	for i, word := range words {
+		words[i] = strings.ToLower(word)
+	}
+	return words
+}
+
+// camelcase converts an identifier to upper camel case, e.g. "http_server" becomes "HttpServer".
+func camelcase(s string) string {
+	var b strings.Builder
+	for _, word := range lowerWords(s) {
+		runes := []rune(word)
+		runes[0] = unicode.ToUpper(runes[0])
+		b.WriteString(string(runes))
+	}
+	return b.String()
+}
+
+// toList converts a slice or array of any element type to a []any.
+func toList(v any) ([]any, error) {
+	if v == nil {
+		return nil, nil
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, fmt.Errorf("expected a list, got %T", v)
+	}
+	list := make([]any, rv.Len())
+	for i := range list {
+		list[i] = rv.Index(i).Interface()
+	}
+	return list, nil
+}
+
+// join concatenates the elements of a list with the given separator.
+func join(sep string, v any) (string, error) {
+	list, err := toList(v)
+	if err != nil {
+		return "", err
+	}
+	parts := make([]string, 0, len(list))
+	for _, item := range list {
+		parts = append(parts, fmt.Sprint(item))
+	}
+	return strings.Join(parts, sep), nil
+}
+
+// first returns the first element of a list.
+func first(v any) (any, error) {
+	list, err := toList(v)
+	if err != nil || len(list) == 0 {
+		return nil, err
+	}
+	return list[0], nil
+}
+
+// last returns the last element of a list.
+func last(v any) (any, error) {
+	list, err := toList(v)
+	if err != nil || len(list) == 0 {
+		return nil, err
+	}
+	return list[len(list)-1], nil
+}
+
+// rest returns all but the first element of a list.
+func rest(v any) ([]any, error) {
+	list, err := toList(v)
+	if err != nil || len(list) == 0 {
+		return nil, err
+	}
+	return list[1:], nil
+}
+
+// appendList returns a new list with the item appended.
+func appendList(v any, item any) ([]any, error) {
+	list, err := toList(v)
+	if err != nil {
+		return nil, err
+	}
+	return append(append([]any{}, list...), item), nil
+}
+
+// has reports whether the list contains the needle.
+func has(needle any, v any) (bool, error) {
+	list, err := toList(v)
+	if err != nil {
+		return false, err
+	}
+	for _, item := range list {
+		if reflect.DeepEqual(item, needle) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// uniq returns the list without duplicate elements, keeping the first occurrence.
+func uniq(v any) ([]any, error) {
+	list, err := toList(v)
+	if err != nil {
+		return nil, err
+	}
+	var unique []any
+	for _, item := range list {
+		duplicate := false
+		for _, seen := range unique {
+			if reflect.DeepEqual(item, seen) {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			unique = append(unique, item)
+		}
+	}
+	return unique, nil
+}
+
+// sortAlpha returns the elements of a list as strings in alphabetical order.
+func sortAlpha(v any) ([]string, error) {
+	list, err := toList(v)
+	if err != nil {
+		return nil, err
+	}
+	sorted := make([]string, 0, len(list))
+	for _, item := range list {
+		sorted = append(sorted, fmt.Sprint(item))
+	}
+	sort.Strings(sorted)
+	return sorted, nil
+}
+
+// dict creates a dictionary from alternating keys and values.
+func dict(pairs ...any) (map[string]any, error) {
+	if len(pairs)%2 != 0 {
+		return nil, fmt.Errorf("dict requires an even number of arguments, got %d", len(pairs))
+	}
+	d := make(map[string]any, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		d[fmt.Sprint(pairs[i])] = pairs[i+1]
+	}
+	return d, nil
+}
+
+// keys returns the keys of a dictionary in sorted order.
+func keys(d map[string]any) []string {
+	k := make([]string, 0, len(d))
+	for key := range d {
+		k = append(k, key)
+	}
+	sort.Strings(k)
+	return k
+}
+
+// merge deep-merges dictionaries into a new dictionary.
+// As in Helm, the first dictionary takes precedence over the following ones.
+func merge(dicts ...map[string]any) map[string]any {
+	reversed := make([]map[string]any, 0, len(dicts))
+	for i := len(dicts) - 1; i >= 0; i-- {
+		reversed = append(reversed, dicts[i])
+	}
+	return mergeValues(reversed...)
+}
+
+// empty reports whether a value is nil, zero or an empty collection.
+func empty(v any) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return rv.Len() == 0
+	case reflect.Pointer, reflect.Interface:
+		return rv.IsNil()
+	default:
+		return rv.IsZero()
+	}
+}
+
+// defaultValue returns the value, or def if the value is empty.
+func defaultValue(def, v any) any {
+	if empty(v) {
+		return def
+	}
+	return v
+}
+
+// coalesce returns the first non-empty value.
+func coalesce(values ...any) any {
+	for _, v := range values {
+		if !empty(v) {
+			return v
+		}
+	}
+	return nil
+}
+
+// ternary returns whenTrue if the condition holds and whenFalse otherwise.
+func ternary(condition bool, whenTrue, whenFalse any) any {
+	if condition {
+		return whenTrue
+	}
+	return whenFalse
+}
+
+// required returns the value, or an error with the given message if the value is empty.
+func required(msg string, v any) (any, error) {
+	if empty(v) {
+		return nil, errors.New(msg)
+	}
+	return v, nil
+}
+
+// toJSON encodes a value as compact JSON.
+func toJSON(v any) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("encoding JSON: %w", err)
+	}
+	return string(data), nil
+}
+
+// toPrettyJSON encodes a value as indented JSON.
+func toPrettyJSON(v any) (string, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("encoding JSON: %w", err)
+	}
+	return string(data), nil
+}
+
+// fromJSON decodes a JSON document.
+func fromJSON(s string) (any, error) {
+	var v any
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		return nil, fmt.Errorf("decoding JSON: %w", err)
+	}
+	return v, nil
+}
+
+// toYAML encodes a value as YAML without a trailing newline, so that it can be piped into indent.
+func toYAML(v any) (string, error) {
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("encoding YAML: %w", err)
+	}
+	return strings.TrimSuffix(string(data), "\n"), nil
+}
+
+// fromYAML decodes a YAML document.
+func fromYAML(s string) (any, error) {
+	var v any
+	if err := yaml.Unmarshal([]byte(s), &v); err != nil {
+		return nil, fmt.Errorf("decoding YAML: %w", err)
+	}
+	return v, nil
+}
+
+// b64dec decodes a base64 encoded string.
+func b64dec(s string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return "", fmt.Errorf("decoding base64: %w", err)
+	}
+	return string(data), nil
+}
+
+// date formats a time with a Go layout such as "2006-01-02".
+// The time may be a time.Time or a Unix timestamp in seconds.
+func date(layout string, t any) (string, error) {
+	switch v := t.(type) {
+	case time.Time:
+		return v.Format(layout), nil
+	case *time.Time:
+		return v.Format(layout), nil
+	default:
+		seconds, err := toInt64(t)
+		if err != nil {
+			return "", fmt.Errorf("date expects a time or Unix timestamp, got %T", t)
+		}
+		return time.Unix(seconds, 0).UTC().Format(layout), nil
+	}
+}
+
+// toInt64 converts numbers and numeric strings to an int64.
+func toInt64(v any) (int64, error) {
+	switch n := v.(type) {
+	case int:
+		return int64(n), nil
+	case int8:
+		return int64(n), nil
+	case int16:
+		return int64(n), nil
+	case int32:
+		return int64(n), nil
+	case int64:
+		return n, nil
+	case uint:
+		return int64(n), nil
+	case uint8:
+		return int64(n), nil
+	case uint16:
+		return int64(n), nil
+	case uint32:
+		return int64(n), nil
+	case uint64:
+		return int64(n), nil
+	case float32:
+		return int64(n), nil
+	case float64:
+		return int64(n), nil
+	case string:
+		return strconv.ParseInt(strings.TrimSpace(n), 10, 64)
+	default:
+		return 0, fmt.Errorf("expected a number, got %T", v)
+	}
+}
+
+// arithmetic returns a template function applying op to two numbers.
+func arithmetic(op func(a, b int64) int64) func(a, b any) (int64, error) {
+	return func(a, b any) (int64, error) {
+		x, err := toInt64(a)
+		if err != nil {
+			return 0, err
+		}
+		y, err := toInt64(b)
+		if err != nil {
+			return 0, err
+		}
+		return op(x, y), nil
+	}
+}
+
+// division returns a template function applying op to two numbers, guarding against division by zero.
+func division(op func(a, b int64) int64) func(a, b any) (int64, error) {
+	return func(a, b any) (int64, error) {
+		y, err := toInt64(b)
+		if err != nil {
+			return 0, err
+		}
+		if y == 0 {
+			return 0, errors.New("division by zero")
+		}
+		return arithmetic(op)(a, y)
+	}
+}
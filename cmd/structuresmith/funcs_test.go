@@ -0,0 +1,127 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTemplateFuncs(t *testing.T) {
+	values := map[string]any{
+		"name":   "structuresmith",
+		"empty":  "",
+		"list":   []any{"b", "a", "b"},
+		"nested": map[string]any{"key": "value", "count": 2},
+		"year":   time.Date(2024, 5, 17, 0, 0, 0, 0, time.UTC),
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		want     string
+		wantErr  bool
+	}{
+		{name: "upper", template: `{{ .name | upper }}`, want: "STRUCTURESMITH"},
+		{name: "lower", template: `{{ "ABC" | lower }}`, want: "abc"},
+		{name: "title", template: `{{ "hello go-world" | title }}`, want: "Hello Go-World"},
+		{name: "trim", template: `{{ "  x  " | trim }}`, want: "x"},
+		{name: "trimPrefix", template: `{{ "v1.2.3" | trimPrefix "v" }}`, want: "1.2.3"},
+		{name: "trimSuffix", template: `{{ "main.go" | trimSuffix ".go" }}`, want: "main"},
+		{name: "replace", template: `{{ "a-b-c" | replace "-" "_" }}`, want: "a_b_c"},
+		{name: "contains", template: `{{ .name | contains "smith" }}`, want: "true"},
+		{name: "hasPrefix", template: `{{ .name | hasPrefix "struct" }}`, want: "true"},
+		{name: "repeat", template: `{{ "ab" | repeat 3 }}`, want: "ababab"},
+		{name: "trunc", template: `{{ .name | trunc 6 }}`, want: "struct"},
+		{name: "trunc negative", template: `{{ .name | trunc -5 }}`, want: "smith"},
+		{name: "nospace", template: `{{ " a b  c " | nospace }}`, want: "abc"},
+		{name: "quote", template: `{{ .name | quote }}`, want: `"structuresmith"`},
+		{name: "squote", template: `{{ .name | squote }}`, want: `'structuresmith'`},
+		{name: "indent", template: `{{ "a\nb" | indent 2 }}`, want: "  a\n  b"},
+		{name: "nindent", template: `{{ "a\nb" | nindent 2 }}`, want: "\n  a\n  b"},
+		{name: "camelcase", template: `{{ "http_server" | camelcase }}`, want: "HttpServer"},
+		{name: "snakecase", template: `{{ "HTTPServerName" | snakecase }}`, want: "http_server_name"},
+		{name: "kebabcase", template: `{{ "goProject_files" | kebabcase }}`, want: "go-project-files"},
+		{name: "splitList", template: `{{ index ("a,b" | splitList ",") 1 }}`, want: "b"},
+		{name: "join", template: `{{ .list | join ", " }}`, want: "b, a, b"},
+		{name: "join invalid", template: `{{ .name | join ", " }}`, wantErr: true},
+		{name: "list", template: `{{ list 1 2 3 | join "-" }}`, want: "1-2-3"},
+		{name: "first", template: `{{ .list | first }}`, want: "b"},
+		{name: "last", template: `{{ list 1 2 3 | last }}`, want: "3"},
+		{name: "rest", template: `{{ list 1 2 3 | rest | join "," }}`, want: "2,3"},
+		{name: "append", template: `{{ append .list "c" | join "" }}`, want: "babc"},
+		{name: "has", template: `{{ .list | has "a" }}`, want: "true"},
+		{name: "uniq", template: `{{ .list | uniq | join "" }}`, want: "ba"},
+		{name: "sortAlpha", template: `{{ .list | sortAlpha | join "" }}`, want: "abb"},
+		{name: "dict and get", template: `{{ get (dict "a" 1 "b" 2) "b" }}`, want: "2"},
+		{name: "dict odd arguments", template: `{{ dict "a" }}`, wantErr: true},
+		{name: "set", template: `{{ $d := dict }}{{ $_ := set $d "k" "v" }}{{ $d.k }}`, want: "v"},
+		{name: "hasKey", template: `{{ hasKey .nested "key" }}`, want: "true"},
+		{name: "keys", template: `{{ keys .nested | join "," }}`, want: "count,key"},
+		{name: "merge", template: `{{ (merge (dict "key" "override") .nested).key }}`, want: "override"},
+		{name: "default", template: `{{ .empty | default "fallback" }}`, want: "fallback"},
+		{name: "default missing", template: `{{ .missing | default "fallback" }}`, want: "fallback"},
+		{name: "default set", template: `{{ .name | default "fallback" }}`, want: "structuresmith"},
+		{name: "empty", template: `{{ empty .empty }} {{ empty .list }}`, want: "true false"},
+		{name: "coalesce", template: `{{ coalesce .empty .missing .name }}`, want: "structuresmith"},
+		{name: "ternary", template: `{{ ternary "yes" "no" true }}`, want: "yes"},
+		{name: "required", template: `{{ required "name is required" .missing }}`, wantErr: true},
+		{name: "fail", template: `{{ fail "unsupported" }}`, wantErr: true},
+		{name: "toJson", template: `{{ .nested | toJson }}`, want: `{"count":2,"key":"value"}`},
+		{name: "toPrettyJson", template: `{{ dict "a" 1 | toPrettyJson }}`, want: "{\n  \"a\": 1\n}"},
+		{name: "fromJson", template: `{{ (fromJson "{\"a\": \"b\"}").a }}`, want: "b"},
+		{name: "toYaml", template: `{{ .nested | toYaml }}`, want: "count: 2\nkey: value"},
+		{name: "fromYaml", template: `{{ (fromYaml "a: b").a }}`, want: "b"},
+		{name: "b64enc", template: `{{ "hello" | b64enc }}`, want: "aGVsbG8="},
+		{name: "b64dec", template: `{{ "aGVsbG8=" | b64dec }}`, want: "hello"},
+		{name: "b64dec invalid", template: `{{ "!" | b64dec }}`, wantErr: true},
+		{name: "sha256sum", template: `{{ "hello" | sha256sum | trunc 8 }}`, want: "2cf24dba"},
+		{name: "date", template: `{{ .year | date "2006-01-02" }}`, want: "2024-05-17"},
+		{name: "date from unix", template: `{{ 0 | date "2006" }}`, want: "1970"},
+		{name: "now", template: `{{ if gt (now | date "2006" | atoi) 2000 }}ok{{ end }}`, want: "ok"},
+		{name: "add", template: `{{ add .nested.count 3 }}`, want: "5"},
+		{name: "sub", template: `{{ sub 5 3 }}`, want: "2"},
+		{name: "mul", template: `{{ mul 2 "4" }}`, want: "8"},
+		{name: "div", template: `{{ div 9 2 }}`, want: "4"},
+		{name: "div by zero", template: `{{ div 1 0 }}`, wantErr: true},
+		{name: "mod", template: `{{ mod 9 2 }}`, want: "1"},
+		{name: "toString", template: `{{ toString 42 | quote }}`, want: `"42"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := renderTemplate(tt.name, tt.template, values, renderOptions{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("renderTemplate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && string(got) != tt.want {
+				t.Errorf("renderTemplate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitWords(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{input: "HTTPServer", want: []string{"HTTP", "Server"}},
+		{input: "goProjectFiles", want: []string{"go", "Project", "Files"}},
+		{input: "snake_case-and kebab", want: []string{"snake", "case", "and", "kebab"}},
+		{input: "version2Go", want: []string{"version2", "Go"}},
+		{input: "", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got := splitWords(tt.input)
+			if len(got) != len(tt.want) {
+				t.Fatalf("splitWords(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("splitWords(%q) = %q, want %q", tt.input, got, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// interpolationPattern matches references to environment variables and files in values,
+// e.g. ${env:GITHUB_REPOSITORY}, {{ env "GITHUB_REPOSITORY" }} or ${file:./secrets/token}.
+var interpolationPattern = regexp.MustCompile(`\$\{(env|file):([^}]+)\}|\{\{-?\s*(env)\s+"([^"]+)"\s*-?\}\}`)
+
+// redactedValue replaces interpolated values in output.
+const redactedValue = "***"
+
+// minRedactedLength is the minimum length of interpolated values that are redacted.
+// Shorter values such as "1" or "yes" would mask unrelated output.
+const minRedactedLength = 4
+
+// interpolateValues resolves references to environment variables and files in all values of the configuration.
+// Files are resolved relative to configDir. The resolved values are recorded, so that they can be redacted from output.
+// Every reference that cannot be resolved is reported at the position of its value.
+func (c *ConfigFile) interpolateValues(configDir string) error {
+	var errs ValidationErrors
+	resolve := func(values map[string]any, path string) map[string]any {
+		resolved, problems := c.interpolate(values, path, configDir)
+		if len(problems) > 0 {
+			errs = append(errs, problems...)
+			return values
+		}
+		if values == nil {
+			return nil
+		}
+		return resolved.(map[string]any)
+	}
+
+	c.Globals = resolve(c.Globals, "globals")
+
+	// Sort group names to report problems deterministically
+	groupNames := make([]string, 0, len(c.TemplateGroups))
+	for groupName := range c.TemplateGroups {
+		groupNames = append(groupNames, groupName)
+	}
+	sort.Strings(groupNames)
+
+	for _, groupName := range groupNames {
+		for i, file := range c.TemplateGroups[groupName] {
+			c.TemplateGroups[groupName][i].Values = resolve(file.Values, fmt.Sprintf("templateGroups.%s[%d].values", groupName, i))
+		}
+	}
+	for i, project := range c.Projects {
+		c.Projects[i].Values = resolve(project.Values, fmt.Sprintf("projects[%d].values", i))
+		for j, groupRef := range project.Groups {
+			c.Projects[i].Groups[j].Values = resolve(groupRef.Values, fmt.Sprintf("projects[%d].groups[%d].values", i, j))
+		}
+		for j, file := range project.Files {
+			c.Projects[i].Files[j].Values = resolve(file.Values, fmt.Sprintf("projects[%d].files[%d].values", i, j))
+		}
+	}
+
+	return errs.err()
+}
+
+// interpolate resolves references in a value and all values nested in it.
+// The path locates the value in the configuration, so that problems are reported at its position.
+func (c *ConfigFile) interpolate(value any, path, configDir string) (any, ValidationErrors) {
+	switch v := value.(type) {
+	case map[string]any:
+		resolved := make(map[string]any, len(v))
+		var errs ValidationErrors
+		for key, nested := range v {
+			r, problems := c.interpolate(nested, path+"."+key, configDir)
+			errs = append(errs, problems...)
+			resolved[key] = r
+		}
+		return resolved, errs
+	case []any:
+		resolved := make([]any, len(v))
+		var errs ValidationErrors
+		for i, nested := range v {
+			r, problems := c.interpolate(nested, fmt.Sprintf("%s[%d]", path, i), configDir)
+			errs = append(errs, problems...)
+			resolved[i] = r
+		}
+		return resolved, errs
+	case string:
+		resolved, err := c.interpolateString(v, configDir)
+		if err == nil {
+			return resolved, nil
+		}
+		problems := []error{err}
+		if joined, ok := err.(interface{ Unwrap() []error }); ok {
+			problems = joined.Unwrap()
+		}
+		var errs ValidationErrors
+		for _, problem := range problems {
+			errs = append(errs, c.errorAt(path, "%v", problem))
+		}
+		return resolved, errs
+	default:
+		return value, nil
+	}
+}
+
+// interpolateString replaces all references in a string with the value they refer to.
+func (c *ConfigFile) interpolateString(s, configDir string) (string, error) {
+	var errs []error
+	resolved := interpolationPattern.ReplaceAllStringFunc(s, func(reference string) string {
+		match := interpolationPattern.FindStringSubmatch(reference)
+		kind, name := match[1], strings.TrimSpace(match[2])
+		if kind == "" {
+			kind, name = match[3], strings.TrimSpace(match[4])
+		}
+
+		var value string
+		switch kind {
+		case "env":
+			v, exists := os.LookupEnv(name)
+			if !exists {
+				errs = append(errs, fmt.Errorf("environment variable %s referenced by %s is not set", name, reference))
+				return reference
+			}
+			value = v
+		case "file":
+			path := name
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(configDir, path)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("reading file referenced by %s: %w", reference, err))
+				return reference
+			}
+			// Secret files commonly end with a newline that is not part of the secret
+			value = strings.TrimRight(string(data), "\r\n")
+		}
+
+		c.addSecret(value)
+		return value
+	})
+	return resolved, errors.Join(errs...)
+}
+
+// addSecret records a resolved value, so that it is redacted from output.
+// The lines of multi-line values are recorded as well, since diffs print every line separately.
+func (c *ConfigFile) addSecret(value string) {
+	if strings.Contains(value, "\n") {
+		for _, line := range strings.Split(value, "\n") {
+			c.addSecret(strings.TrimRight(line, "\r"))
+		}
+	}
+	if len(value) < minRedactedLength {
+		return
+	}
+	for _, secret := range c.secrets {
+		if secret == value {
+			return
+		}
+	}
+	c.secrets = append(c.secrets, value)
+
+	// Replace longer secrets first, so that secrets containing others are fully redacted
+	sort.Slice(c.secrets, func(i, j int) bool {
+		return len(c.secrets[i]) > len(c.secrets[j])
+	})
+}
+
+// redact replaces all values resolved from environment variables and files in s.
+func (c ConfigFile) redact(s string) string {
+	for _, secret := range c.secrets {
+		s = strings.ReplaceAll(s, secret, redactedValue)
+	}
+	return s
+}
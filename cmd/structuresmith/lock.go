@@ -1,6 +1,8 @@
 package main
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -104,31 +106,77 @@ func LoadLockFile(dir string) (*AnvilLock, error) {
 	return &lock, nil
 }
 
+// LoadLockFileIfExists loads the anvil.lock file from a given directory without creating it.
+// It returns an empty AnvilLock if the directory contains no lock file.
+func LoadLockFileIfExists(dir string) (*AnvilLock, error) {
+	lockFilePath := filepath.Join(dir, ".anvil.lock")
+	if _, err := os.Stat(lockFilePath); os.IsNotExist(err) {
+		return &AnvilLock{Files: []AnvilLockFileEntry{}}, nil
+	}
+	return LoadLockFile(dir)
+}
+
 // convertToFileEntry converts a FileStructure to an AnvilLockFileEntry.
 func (a *AnvilLock) convertToFileEntry(fileStructure FileStructure) AnvilLockFileEntry {
-	// TODO: Implement checksum calculation.
 	return AnvilLockFileEntry{
 		Path:     fileStructure.Destination,
-		Checksum: "", // Placeholder for checksum.
+		Checksum: fileStructure.Checksum,
+	}
+}
+
+// findChecksum returns the checksum recorded for the given path.
+// It returns an empty string if the path is not tracked or has no checksum.
+func (a *AnvilLock) findChecksum(path string) string {
+	for _, entry := range a.Files {
+		if entry.Path == path {
+			return entry.Checksum
+		}
+	}
+	return ""
+}
+
+// checksumPrefix identifies the hash algorithm used for lock file checksums.
+const checksumPrefix = "sha256:"
+
+// computeChecksum returns the SHA-256 checksum of the given content.
+func computeChecksum(content []byte) string {
+	sum := sha256.Sum256(content)
+	return checksumPrefix + hex.EncodeToString(sum[:])
+}
+
+// computeFileChecksum returns the SHA-256 checksum of the file at the given path.
+func computeFileChecksum(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
 	}
+	return computeChecksum(content), nil
 }
 
 // FileStatus constants representing the status of a file in the diff.
 type FileStatus string
 
 const (
-	StatusNew     FileStatus = "New"
-	StatusDeleted FileStatus = "Deleted"
-	StatusKept    FileStatus = "Kept"
-	StatusSkipped FileStatus = "Skipped"
+	StatusNew       FileStatus = "New"
+	StatusDeleted   FileStatus = "Deleted"
+	StatusKept      FileStatus = "Kept"
+	StatusSkipped   FileStatus = "Skipped"
+	StatusModified  FileStatus = "Modified"
+	StatusUnchanged FileStatus = "Unchanged"
 )
 
 // DiffResult represents the result of diffing FileStructures against AnvilLock entries.
 type DiffResult struct {
-	NewFiles     []FileStructure // Files present in FileStructures but not in AnvilLock.
-	DeletedFiles []FileStructure // Files present in AnvilLock but not in FileStructures.
-	KeptFiles    []FileStructure // Files present in both AnvilLock and FileStructures.
-	SkippedFiles []FileStructure // Files that exist on disk and have overwrite: false.
+	NewFiles       []FileStructure // Files present in FileStructures but not in AnvilLock.
+	DeletedFiles   []FileStructure // Files present in AnvilLock but not in FileStructures.
+	KeptFiles      []FileStructure // Files present in both AnvilLock and FileStructures.
+	SkippedFiles   []FileStructure // Files that exist on disk and have overwrite: false.
+	ModifiedFiles  []FileStructure // Files whose content on disk differs from the checksum in AnvilLock.
+	UnchangedFiles []FileStructure // Files whose content on disk is identical to the rendered content.
+	// ModifiedDeletedFiles are deleted files whose content on disk differs from the checksum in AnvilLock.
+	ModifiedDeletedFiles []FileStructure
+	// Patches holds the unified diffs between the files on disk and the rendered content, keyed by destination.
+	Patches map[string]string
 }
 
 func (d DiffResult) String() string {
@@ -147,6 +195,15 @@ func (d DiffResult) String() string {
 	for _, file := range d.SkippedFiles {
 		fileMap[file.Destination] = StatusSkipped
 	}
+	for _, file := range d.ModifiedFiles {
+		fileMap[file.Destination] = StatusModified
+	}
+	for _, file := range d.ModifiedDeletedFiles {
+		fileMap[file.Destination] = StatusModified
+	}
+	for _, file := range d.UnchangedFiles {
+		fileMap[file.Destination] = StatusUnchanged
+	}
 
 	// Sort the keys (file paths)
 	keys := make([]string, 0, len(fileMap))
@@ -169,6 +226,34 @@ func (d DiffResult) String() string {
 	if err := writer.Flush(); err != nil {
 		return "Error generating output"
 	}
+
+	// Append the unified diffs of changed files
+	for _, key := range keys {
+		if patch, exists := d.Patches[key]; exists {
+			result.WriteString("\n")
+			result.WriteString(colorizePatch(patch))
+		}
+	}
+	return result.String()
+}
+
+// colorizePatch colors the lines of a unified diff.
+func colorizePatch(patch string) string {
+	var result strings.Builder
+	for _, line := range strings.SplitAfter(patch, "\n") {
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+			result.WriteString(color.New(color.Bold).Sprint(line))
+		case strings.HasPrefix(line, "@@"):
+			result.WriteString(color.New(color.FgCyan).Sprint(line))
+		case strings.HasPrefix(line, "+"):
+			result.WriteString(color.New(color.FgGreen).Sprint(line))
+		case strings.HasPrefix(line, "-"):
+			result.WriteString(color.New(color.FgRed).Sprint(line))
+		default:
+			result.WriteString(line)
+		}
+	}
 	return result.String()
 }
 
@@ -182,6 +267,10 @@ func getColorAndPrefix(status FileStatus) string {
 		return color.New(color.FgYellow).Sprintf("overwrite:")
 	case StatusSkipped:
 		return color.New(color.FgCyan).Sprintf("skip:")
+	case StatusModified:
+		return color.New(color.FgMagenta).Sprintf("modified:")
+	case StatusUnchanged:
+		return color.New(color.Faint).Sprintf("unchanged:")
 	default:
 		return "n/a: "
 	}
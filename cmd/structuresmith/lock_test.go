@@ -244,3 +244,57 @@ func TestLoadLockFile(t *testing.T) {
 		})
 	}
 }
+
+func TestConvertToFileEntry(t *testing.T) {
+	tests := []struct {
+		name string
+		file FileStructure
+		want AnvilLockFileEntry
+	}{
+		{
+			name: "File With Checksum",
+			file: FileStructure{Destination: "file1.txt", Checksum: computeChecksum([]byte("content"))},
+			want: AnvilLockFileEntry{Path: "file1.txt", Checksum: "sha256:ed7002b439e9ac845f22357d822bac1444730fbdb6016d3ec9432297b9ec9f73"},
+		},
+		{
+			name: "File Without Checksum",
+			file: FileStructure{Destination: "file2.txt"},
+			want: AnvilLockFileEntry{Path: "file2.txt"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lock := AnvilLock{}
+			got := lock.convertToFileEntry(tt.file)
+			if got != tt.want {
+				t.Errorf("convertToFileEntry() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindChecksum(t *testing.T) {
+	lock := AnvilLock{Files: []AnvilLockFileEntry{
+		{Path: "file1.txt", Checksum: "sha256:abc"},
+		{Path: "file2.txt"},
+	}}
+
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{name: "Tracked File With Checksum", path: "file1.txt", want: "sha256:abc"},
+		{name: "Tracked File Without Checksum", path: "file2.txt", want: ""},
+		{name: "Untracked File", path: "file3.txt", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := lock.findChecksum(tt.path); got != tt.want {
+				t.Errorf("findChecksum() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,9 +1,12 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"os"
 	"runtime"
+	"strings"
 	"time"
 
 	"github.com/alecthomas/kong"
@@ -20,29 +23,64 @@ var (
 // CLI struct defines the command line arguments.
 var CLI struct {
 	Validate struct {
-		GlobalArgs
+		ValidateArgs
 	} `cmd:"" help:"Validates the YAML configuration to ensure its integrity and checks for any potential issues."`
 
 	Diff struct {
-		DiffArgs
+		ReportArgs
 	} `cmd:"" help:"Conducts a dry-run to display the file paths that would be generated, helping to preview changes without actual file creation."`
 
 	Render struct {
-		DiffArgs
+		RenderArgs
 	} `cmd:"" help:"Processes and writes the templated files to the disk, applying the configurations to generate the specified project structure."`
+
+	Check struct {
+		ReportArgs
+	} `cmd:"" help:"Checks the output directory for drift from the configuration and exits with a non-zero code if any is found."`
+}
+
+// Exit codes of the 'check' command.
+const (
+	exitCodeDrift = 1 // Drift between the configuration and the output directory was detected.
+	exitCodeError = 2 // The check could not be performed.
+)
+
+// ValidateArgs struct for validate related arguments.
+type ValidateArgs struct {
+	GlobalArgs
+	Values []string `name:"values" help:"YAML or JSON files with values that take precedence over all values in the config" type:"path"`
+	Set    []string `name:"set" help:"Set a value that takes precedence over all others, e.g. --set image.tag=1.2.3" sep:"none"`
+	Format string   `name:"format" help:"Output format of validation errors, one of: text, json" enum:"text,json" default:"text"`
 }
 
 // DiffArgs struct for diff related arguments.
 type DiffArgs struct {
 	GlobalArgs
-	Project string `arg:"project" help:"The project in the config to render or diff"`
+	Projects []string `arg:"" name:"project" optional:"" help:"The projects in the config to render or diff. Accepts project names, glob patterns such as 'example/*' and regular expressions prefixed with 're:'"`
+	All      bool     `name:"all" help:"Select all projects in the config"`
+	Strict   bool     `name:"strict" help:"Fail on missing keys and template errors instead of copying templates as is"`
+	Values   []string `name:"values" help:"YAML or JSON files with values that take precedence over all values in the config" type:"path"`
+	Set      []string `name:"set" help:"Set a value that takes precedence over all others, e.g. --set image.tag=1.2.3" sep:"none"`
+}
+
+// ReportArgs struct for commands that report on the state of the output directory.
+type ReportArgs struct {
+	DiffArgs
+	Format string `name:"format" help:"Output format, one of: text, json, yaml, junit, sarif" enum:"text,json,yaml,junit,sarif" default:"text"`
+}
+
+// RenderArgs struct for render related arguments.
+type RenderArgs struct {
+	DiffArgs
+	Force bool `name:"force" help:"Overwrite files that were modified by hand since the last render"`
 }
 
 // GlobalArgs struct for global arguments.
 type GlobalArgs struct {
 	ConfigFile   string `name:"config" help:"Path to the YAML configuration file" type:"path" default:"anvil.yml"`
-	OutputPath   string `name:"output" help:"Output path prefix for generated files" type:"path" default:"out"`
+	OutputPath   string `name:"output" help:"Output path prefix for generated files (default: the output of the project or \"out\"). May be a template such as 'repos/{{ .Project.Name }}'" type:"path"`
 	TemplatesDir string `name:"templates" help:"Directory where template files are stored" type:"path" default:"templates"`
+	PartialsDir  string `name:"partials" help:"Directory of shared template partials (*.tmpl), relative to the templates directory" default:"${partialsDir}"`
 }
 
 func main() {
@@ -57,55 +95,115 @@ func main() {
 				GoVersion,
 			),
 		),
+		kong.Vars{"partialsDir": DefaultPartialsDir},
 	)
-	switch ctx.Command() {
+	// Project arguments are optional, so only the command name is relevant
+	switch strings.Fields(ctx.Command())[0] {
 	case "validate":
-		executeValidateCommand(CLI.Validate.GlobalArgs)
-	case "diff <project>":
-		executeDiffCommand(CLI.Diff.DiffArgs)
-	case "render <project>":
-		executeRenderCommand(CLI.Render.DiffArgs)
+		executeValidateCommand(CLI.Validate.ValidateArgs)
+	case "diff":
+		executeDiffCommand(CLI.Diff.ReportArgs)
+	case "render":
+		executeRenderCommand(CLI.Render.RenderArgs)
+	case "check":
+		executeCheckCommand(CLI.Check.ReportArgs)
 	default:
 		panic(ctx.Command())
 	}
 }
 
 // executeValidateCommand handles the 'validate' command.
-func executeValidateCommand(args GlobalArgs) {
+func executeValidateCommand(args ValidateArgs) {
+	overrides, err := loadOverrides(args.Values, args.Set)
+	if err != nil {
+		log.Fatalf("Invalid values: %v\n", err)
+	}
+
 	app := newStructuresmith(Options{
 		ConfigFile:   args.ConfigFile,
 		OutputDir:    args.OutputPath,
 		TemplatesDir: args.TemplatesDir,
+		PartialsDir:  args.PartialsDir,
+		Overrides:    overrides,
 	})
 
-	if _, err := app.loadAndValidateConfig(); err != nil {
+	cfg, err := app.loadAndValidateConfig()
+	if err == nil {
+		err = app.validateProjects(cfg)
+	}
+	if args.Format == FormatJSON {
+		var validationErrs ValidationErrors
+		if err != nil && !errors.As(err, &validationErrs) {
+			validationErrs = ValidationErrors{{File: displayPath(args.ConfigFile), Message: err.Error()}}
+		}
+		output, formatErr := formatValidationErrors(validationErrs)
+		if formatErr != nil {
+			log.Fatalf("Configuration validation error: %v\n", formatErr)
+		}
+		fmt.Print(output)
+		if len(validationErrs) > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+
+	var validationErrs ValidationErrors
+	if errors.As(err, &validationErrs) {
+		for _, validationErr := range validationErrs {
+			fmt.Fprintln(os.Stderr, validationErr)
+		}
+		log.Fatalf("Configuration validation failed with %d error(s)\n", len(validationErrs))
+	}
+	if err != nil {
 		log.Fatalf("Configuration validation error: %v\n", err)
 	}
 }
 
 // executeDiffCommand handles the 'diff' command.
-func executeDiffCommand(args DiffArgs) {
+func executeDiffCommand(args ReportArgs) {
+	overrides, err := loadOverrides(args.Values, args.Set)
+	if err != nil {
+		log.Fatalf("Invalid values: %v\n", err)
+	}
+
 	app := newStructuresmith(Options{
 		ConfigFile:   args.ConfigFile,
 		OutputDir:    args.OutputPath,
 		TemplatesDir: args.TemplatesDir,
+		PartialsDir:  args.PartialsDir,
+		Strict:       args.Strict,
+		Overrides:    overrides,
+		Format:       args.Format,
 	})
 	cfg, err := app.loadAndValidateConfig()
 	if err != nil {
 		log.Fatalf("Configuration validation error: %v\n", err)
 	}
 
-	if err := app.diff(args.Project, cfg); err != nil {
-		log.Fatalf("Configuration diff error: %v\n", err)
+	_, err = app.runProjects(cfg, args.Projects, args.All, func(app *Structuresmith, p Project) (fmt.Stringer, Report, error) {
+		result, err := app.diff(p.Name, cfg)
+		return result, result.Report(p.Name, app.OutputDir), err
+	})
+	if err != nil {
+		log.Fatalf("Configuration diff error: %s\n", cfg.redact(err.Error()))
 	}
 }
 
 // executeRenderCommand handles the 'render' command.
-func executeRenderCommand(args DiffArgs) {
+func executeRenderCommand(args RenderArgs) {
+	overrides, err := loadOverrides(args.Values, args.Set)
+	if err != nil {
+		log.Fatalf("Invalid values: %v\n", err)
+	}
+
 	app := newStructuresmith(Options{
 		ConfigFile:   args.ConfigFile,
 		OutputDir:    args.OutputPath,
 		TemplatesDir: args.TemplatesDir,
+		PartialsDir:  args.PartialsDir,
+		Strict:       args.Strict,
+		Overrides:    overrides,
+		Force:        args.Force,
 	})
 
 	cfg, err := app.loadAndValidateConfig()
@@ -113,7 +211,51 @@ func executeRenderCommand(args DiffArgs) {
 		log.Fatalf("Configuration validation error: %v\n", err)
 	}
 
-	if err := app.render(args.Project, cfg); err != nil {
-		log.Fatalf("Configuration diff error: %v\n", err)
+	_, err = app.runProjects(cfg, args.Projects, args.All, func(app *Structuresmith, p Project) (fmt.Stringer, Report, error) {
+		result, err := app.render(p.Name, cfg)
+		return result, result.Report(p.Name, app.OutputDir), err
+	})
+	if err != nil {
+		log.Fatalf("Configuration diff error: %s\n", cfg.redact(err.Error()))
+	}
+}
+
+// executeCheckCommand handles the 'check' command.
+func executeCheckCommand(args ReportArgs) {
+	overrides, err := loadOverrides(args.Values, args.Set)
+	if err != nil {
+		log.Printf("Invalid values: %v\n", err)
+		os.Exit(exitCodeError)
+	}
+
+	app := newStructuresmith(Options{
+		ConfigFile:   args.ConfigFile,
+		OutputDir:    args.OutputPath,
+		TemplatesDir: args.TemplatesDir,
+		PartialsDir:  args.PartialsDir,
+		Strict:       args.Strict,
+		Overrides:    overrides,
+		Format:       args.Format,
+	})
+
+	cfg, err := app.loadAndValidateConfig()
+	if err != nil {
+		log.Printf("Configuration validation error: %v\n", err)
+		os.Exit(exitCodeError)
+	}
+
+	reports, err := app.runProjects(cfg, args.Projects, args.All, func(app *Structuresmith, p Project) (fmt.Stringer, Report, error) {
+		result, err := app.check(p.Name, cfg)
+		return result, result.Report(p.Name, app.OutputDir), err
+	})
+	if err != nil {
+		log.Printf("Configuration check error: %s\n", cfg.redact(err.Error()))
+		os.Exit(exitCodeError)
+	}
+
+	for _, report := range reports {
+		if report.Failures() > 0 {
+			os.Exit(exitCodeDrift)
+		}
 	}
 }
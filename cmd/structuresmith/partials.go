@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/template"
+)
+
+// DefaultPartialsDir is the directory of shared partials, relative to the templates directory.
+const DefaultPartialsDir = "_partials"
+
+// partialsPattern matches the files in the partials directory that are loaded as partials.
+const partialsPattern = "*.tmpl"
+
+// maxIncludeDepth limits nested calls of the include function to guard against infinite recursion.
+const maxIncludeDepth = 100
+
+// Partials maps the names of shared template snippets to their content.
+// Names are slash-separated paths relative to the partials directory, e.g. "license-header.tmpl".
+type Partials map[string]string
+
+// partialsPath returns the location of the partials directory.
+// Relative directories are resolved against the templates directory.
+func (app *Structuresmith) partialsPath() string {
+	if app.PartialsDir == "" || filepath.IsAbs(app.PartialsDir) {
+		return app.PartialsDir
+	}
+	return filepath.Join(app.TemplatesDir, app.PartialsDir)
+}
+
+// loadPartials reads all partials of a directory, including its subdirectories.
+// A missing directory results in no partials. Every partial is parsed to report syntax errors early.
+func loadPartials(dir string) (Partials, error) {
+	partials := make(Partials)
+	if dir == "" {
+		return partials, nil
+	}
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if matched, _ := filepath.Match(partialsPattern, d.Name()); !matched {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("reading partial: %w", err)
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return fmt.Errorf("error getting relative path: %w", err)
+		}
+		partials[filepath.ToSlash(rel)] = string(content)
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return partials, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error loading partials from %s: %w", dir, err)
+	}
+
+	if _, err := newTemplate("partials", renderOptions{Partials: partials}); err != nil {
+		return nil, err
+	}
+	return partials, nil
+}
+
+// newTemplate creates a template with the template functions and all partials loaded.
+// The include function executes a partial or a defined template and returns its output as a string.
+func newTemplate(name string, opts renderOptions) (*template.Template, error) {
+	tmpl := template.New(name)
+	if opts.Strict {
+		tmpl.Option("missingkey=error")
+	}
+
+	depth := 0
+	funcs := templateFuncs()
+	funcs["include"] = func(name string, data any) (string, error) {
+		if depth >= maxIncludeDepth {
+			return "", fmt.Errorf("include %s: maximum include depth of %d exceeded", name, maxIncludeDepth)
+		}
+		depth++
+		defer func() { depth-- }()
+
+		var buf bytes.Buffer
+		if err := tmpl.ExecuteTemplate(&buf, name, data); err != nil {
+			return "", err
+		}
+		return buf.String(), nil
+	}
+	tmpl.Funcs(funcs)
+
+	names := make([]string, 0, len(opts.Partials))
+	for partialName := range opts.Partials {
+		names = append(names, partialName)
+	}
+	sort.Strings(names)
+	for _, partialName := range names {
+		if _, err := tmpl.New(partialName).Parse(opts.Partials[partialName]); err != nil {
+			return nil, newTemplateError(partialName, err)
+		}
+	}
+	return tmpl, nil
+}
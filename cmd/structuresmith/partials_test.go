@@ -0,0 +1,95 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadPartials(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"license-header.tmpl":  "// Copyright {{ .Author }}",
+		"badges/ci.tmpl":       `{{ define "badge" }}![CI]({{ . }}){{ end }}`,
+		"README.md":            "not a partial",
+		"badges/notes.txt":     "not a partial",
+		"nested/deep/foo.tmpl": "foo",
+	}
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write partial: %v", err)
+		}
+	}
+
+	got, err := loadPartials(tmpDir)
+	if err != nil {
+		t.Fatalf("loadPartials() error = %v", err)
+	}
+	want := Partials{
+		"license-header.tmpl":  "// Copyright {{ .Author }}",
+		"badges/ci.tmpl":       `{{ define "badge" }}![CI]({{ . }}){{ end }}`,
+		"nested/deep/foo.tmpl": "foo",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("loadPartials() = %v, want %v", got, want)
+	}
+
+	t.Run("Missing Directory", func(t *testing.T) {
+		got, err := loadPartials(filepath.Join(tmpDir, "missing"))
+		if err != nil {
+			t.Fatalf("loadPartials() error = %v", err)
+		}
+		if len(got) != 0 {
+			t.Errorf("loadPartials() = %v, want no partials", got)
+		}
+	})
+
+	t.Run("Syntax Error", func(t *testing.T) {
+		brokenDir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(brokenDir, "broken.tmpl"), []byte("{{ .Author "), 0o644); err != nil {
+			t.Fatalf("Failed to write partial: %v", err)
+		}
+		if _, err := loadPartials(brokenDir); err == nil {
+			t.Error("loadPartials() expected error for invalid partial")
+		}
+	})
+}
+
+func TestRenderTemplateWithPartials(t *testing.T) {
+	partials := Partials{
+		"license-header.tmpl": "Copyright {{ .Author }}\nAll rights reserved.",
+		"badges.tmpl":         `{{ define "badge" }}![{{ .name }}]({{ .url }}){{ end }}`,
+		"recursive.tmpl":      `{{ include "recursive.tmpl" . }}`,
+	}
+	values := map[string]any{"Author": "Alice"}
+
+	tests := []struct {
+		name     string
+		template string
+		want     string
+		wantErr  bool
+	}{
+		{name: "Template Action", template: `{{ template "license-header.tmpl" . }}`, want: "Copyright Alice\nAll rights reserved."},
+		{name: "Include With Indent", template: "/*\n{{ include \"license-header.tmpl\" . | indent 2 }}\n*/", want: "/*\n  Copyright Alice\n  All rights reserved.\n*/"},
+		{name: "Defined Template", template: `{{ include "badge" (dict "name" "CI" "url" "ci.svg") }}`, want: "![CI](ci.svg)"},
+		{name: "Unknown Partial", template: `{{ include "missing.tmpl" . }}`, wantErr: true},
+		{name: "Recursive Include", template: `{{ include "recursive.tmpl" . }}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := renderTemplate("file", tt.template, values, renderOptions{Partials: partials})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("renderTemplate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && string(got) != tt.want {
+				t.Errorf("renderTemplate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,393 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Output formats supported by the diff and check commands.
+const (
+	FormatText  = "text"
+	FormatJSON  = "json"
+	FormatYAML  = "yaml"
+	FormatJUnit = "junit"
+	FormatSARIF = "sarif"
+)
+
+// Source kinds of a file in a report.
+const (
+	SourceKindContent   = "content"
+	SourceKindSource    = "source"
+	SourceKindSourceURL = "sourceUrl"
+)
+
+// FileReport describes the status of a single file in a machine-readable report.
+type FileReport struct {
+	Status      string `json:"status" yaml:"status"`
+	Destination string `json:"destination" yaml:"destination"`
+	SourceKind  string `json:"sourceKind,omitempty" yaml:"sourceKind,omitempty"`
+	Checksum    string `json:"checksum,omitempty" yaml:"checksum,omitempty"`
+	Patch       string `json:"patch,omitempty" yaml:"patch,omitempty"`
+	Failed      bool   `json:"failed" yaml:"failed"`
+}
+
+// Report is a machine-readable representation of a DiffResult or CheckResult for a single project.
+type Report struct {
+	Command   string       `json:"command" yaml:"command"`
+	Project   string       `json:"project" yaml:"project"`
+	OutputDir string       `json:"outputDir" yaml:"outputDir"`
+	Files     []FileReport `json:"files" yaml:"files"`
+}
+
+// Failures returns the number of files that failed in the report.
+func (r Report) Failures() int {
+	failures := 0
+	for _, file := range r.Files {
+		if file.Failed {
+			failures++
+		}
+	}
+	return failures
+}
+
+// redact returns a copy of the report with secrets replaced in all of its text.
+// Reports are redacted before they are serialized, since the output formats escape special characters.
+func (r Report) redact(redact func(string) string) Report {
+	redacted := r
+	redacted.Project = redact(r.Project)
+	redacted.OutputDir = redact(r.OutputDir)
+	redacted.Files = make([]FileReport, len(r.Files))
+	for i, file := range r.Files {
+		file.Destination = redact(file.Destination)
+		file.Patch = redact(file.Patch)
+		redacted.Files[i] = file
+	}
+	return redacted
+}
+
+// Summary returns a single line with the number of files per status.
+func (r Report) Summary() string {
+	counts := make(map[string]int)
+	for _, file := range r.Files {
+		counts[file.Status]++
+	}
+	if len(counts) == 0 {
+		return "no files"
+	}
+
+	statuses := make([]string, 0, len(counts))
+	for status := range counts {
+		statuses = append(statuses, status)
+	}
+	sort.Strings(statuses)
+
+	parts := make([]string, 0, len(statuses))
+	for _, status := range statuses {
+		parts = append(parts, fmt.Sprintf("%s=%d", status, counts[status]))
+	}
+	return strings.Join(parts, " ")
+}
+
+// sourceKind returns the kind of source a FileStructure is rendered from.
+func sourceKind(file FileStructure) string {
+	switch {
+	case file.Content != "":
+		return SourceKindContent
+	case file.SourceURL != "":
+		return SourceKindSourceURL
+	case file.Source != "":
+		return SourceKindSource
+	default:
+		return ""
+	}
+}
+
+// Report converts the DiffResult into a Report.
+// Files that would be changed by a render are marked as failed.
+func (d DiffResult) Report(project, outputDir string) Report {
+	report := Report{Command: "diff", Project: project, OutputDir: outputDir, Files: []FileReport{}}
+
+	add := func(files []FileStructure, status string, failed bool) {
+		for _, file := range files {
+			report.Files = append(report.Files, FileReport{
+				Status:      status,
+				Destination: file.Destination,
+				SourceKind:  sourceKind(file),
+				Checksum:    file.Checksum,
+				Patch:       d.Patches[file.Destination],
+				Failed:      failed,
+			})
+		}
+	}
+	add(d.NewFiles, "new", true)
+	add(d.DeletedFiles, "delete", true)
+	add(d.KeptFiles, "overwrite", true)
+	add(d.ModifiedFiles, "modified", true)
+	add(d.ModifiedDeletedFiles, "modified", true)
+	add(d.SkippedFiles, "skip", false)
+	add(d.UnchangedFiles, "unchanged", false)
+
+	sortFileReports(report.Files)
+	return report
+}
+
+// Report converts the CheckResult into a Report.
+// Files that drifted from the configuration are marked as failed.
+func (c CheckResult) Report(project, outputDir string) Report {
+	report := Report{Command: "check", Project: project, OutputDir: outputDir, Files: []FileReport{}}
+
+	add := func(files []FileStructure, status string, failed bool) {
+		for _, file := range files {
+			report.Files = append(report.Files, FileReport{
+				Status:      status,
+				Destination: file.Destination,
+				SourceKind:  sourceKind(file),
+				Checksum:    file.Checksum,
+				Failed:      failed,
+			})
+		}
+	}
+	add(c.MissingFiles, "missing", true)
+	add(c.OutdatedFiles, "outdated", true)
+	add(c.OrphanedFiles, "orphaned", true)
+	add(c.ModifiedFiles, "modified", true)
+	add(c.UpToDateFiles, "up-to-date", false)
+
+	sortFileReports(report.Files)
+	return report
+}
+
+// sortFileReports sorts file reports by destination.
+func sortFileReports(files []FileReport) {
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].Destination < files[j].Destination
+	})
+}
+
+// formatReports serializes reports in the given machine-readable format.
+func formatReports(reports []Report, format string) (string, error) {
+	switch format {
+	case FormatJSON:
+		data, err := json.MarshalIndent(reports, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("error marshaling JSON report: %w", err)
+		}
+		return string(data) + "\n", nil
+	case FormatYAML:
+		data, err := yaml.Marshal(reports)
+		if err != nil {
+			return "", fmt.Errorf("error marshaling YAML report: %w", err)
+		}
+		return string(data), nil
+	case FormatJUnit:
+		return formatJUnit(reports)
+	case FormatSARIF:
+		return formatSARIF(reports)
+	default:
+		return "", fmt.Errorf("unsupported output format: %s", format)
+	}
+}
+
+// junitTestSuites is the root element of a JUnit XML report.
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+// junitTestSuite represents a project in a JUnit XML report.
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+// junitTestCase represents a file in a JUnit XML report.
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+// junitFailure describes why a file failed in a JUnit XML report.
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Body    string `xml:",chardata"`
+}
+
+// formatJUnit renders reports as JUnit XML, with one test suite per project and one test case per file.
+func formatJUnit(reports []Report) (string, error) {
+	root := junitTestSuites{Name: "structuresmith"}
+	for _, report := range reports {
+		suite := junitTestSuite{
+			Name:     report.Project,
+			Tests:    len(report.Files),
+			Failures: report.Failures(),
+		}
+		for _, file := range report.Files {
+			testCase := junitTestCase{
+				Name:      file.Destination,
+				ClassName: report.Command + "." + report.Project,
+			}
+			if file.Failed {
+				testCase.Failure = &junitFailure{
+					Message: fmt.Sprintf("%s: %s", file.Status, file.Destination),
+					Type:    file.Status,
+					Body:    file.Patch,
+				}
+			}
+			suite.TestCases = append(suite.TestCases, testCase)
+		}
+		root.Tests += suite.Tests
+		root.Failures += suite.Failures
+		root.Suites = append(root.Suites, suite)
+	}
+
+	data, err := xml.MarshalIndent(root, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("error marshaling JUnit report: %w", err)
+	}
+	return xml.Header + string(data) + "\n", nil
+}
+
+// sarifLog is the root object of a SARIF 2.1.0 report.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+// sarifRun describes a single run of structuresmith in a SARIF report.
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+// sarifTool describes structuresmith in a SARIF report.
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+// sarifDriver describes the analysis tool and its rules in a SARIF report.
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+// sarifRule describes a file status in a SARIF report.
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+// sarifResult describes a failed file in a SARIF report.
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+// sarifMessage is a plain text message in a SARIF report.
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+// sarifLocation points to a file in a SARIF report.
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+// sarifPhysicalLocation points to a file in a SARIF report.
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+}
+
+// sarifArtifactLocation holds the URI of a file in a SARIF report.
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+// sarifRuleDescriptions describes the statuses that are reported as SARIF results.
+var sarifRuleDescriptions = map[string]string{
+	"new":       "File would be created",
+	"delete":    "File would be deleted",
+	"overwrite": "File would be overwritten",
+	"modified":  "File was modified by hand since the last render",
+	"missing":   "Managed file is missing",
+	"outdated":  "File differs from the rendered content",
+	"orphaned":  "File is no longer managed but still exists",
+}
+
+// formatSARIF renders the failed files of reports as SARIF 2.1.0 results.
+// File locations are relative to the working directory, so that code scanning can resolve them.
+func formatSARIF(reports []Report) (string, error) {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "structuresmith",
+			Version:        Version,
+			InformationURI: "https://github.com/cbrgm/structuresmith",
+			Rules:          []sarifRule{},
+		}},
+		Results: []sarifResult{},
+	}
+
+	rules := make(map[string]struct{})
+	for _, report := range reports {
+		for _, file := range report.Files {
+			if !file.Failed {
+				continue
+			}
+			if _, exists := rules[file.Status]; !exists {
+				rules[file.Status] = struct{}{}
+				run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+					ID:               file.Status,
+					ShortDescription: sarifMessage{Text: sarifRuleDescriptions[file.Status]},
+				})
+			}
+			run.Results = append(run.Results, sarifResult{
+				RuleID:  file.Status,
+				Level:   "error",
+				Message: sarifMessage{Text: fmt.Sprintf("%s: %s (project %s)", file.Status, file.Destination, report.Project)},
+				Locations: []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: sarifURI(report.OutputDir, file.Destination)},
+				}}},
+			})
+		}
+	}
+
+	data, err := json.MarshalIndent(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	}, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("error marshaling SARIF report: %w", err)
+	}
+	return string(data) + "\n", nil
+}
+
+// sarifURI returns the location of a file in the output directory relative to the working directory.
+// Absolute paths are kept if the file is located outside of the working directory.
+func sarifURI(outputDir, destination string) string {
+	fullPath := filepath.Join(outputDir, destination)
+	if wd, err := os.Getwd(); err == nil && filepath.IsAbs(fullPath) {
+		if rel, err := filepath.Rel(wd, fullPath); err == nil && filepath.IsLocal(rel) {
+			fullPath = rel
+		}
+	}
+	return filepath.ToSlash(fullPath)
+}
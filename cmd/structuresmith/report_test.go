@@ -0,0 +1,183 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestDiffResultReport(t *testing.T) {
+	diff := DiffResult{
+		NewFiles:       []FileStructure{{Destination: "b.txt", Content: "b", Checksum: "sha256:b"}},
+		KeptFiles:      []FileStructure{{Destination: "a.txt", Source: "templates/a.tmpl"}},
+		DeletedFiles:   []FileStructure{{Destination: "c.txt"}},
+		UnchangedFiles: []FileStructure{{Destination: "d.txt", SourceURL: "https://example.com/d"}},
+		Patches:        map[string]string{"a.txt": "patch"},
+	}
+
+	got := diff.Report("project1", "out")
+	want := Report{
+		Command:   "diff",
+		Project:   "project1",
+		OutputDir: "out",
+		Files: []FileReport{
+			{Status: "overwrite", Destination: "a.txt", SourceKind: SourceKindSource, Patch: "patch", Failed: true},
+			{Status: "new", Destination: "b.txt", SourceKind: SourceKindContent, Checksum: "sha256:b", Failed: true},
+			{Status: "delete", Destination: "c.txt", Failed: true},
+			{Status: "unchanged", Destination: "d.txt", SourceKind: SourceKindSourceURL, Failed: false},
+		},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Report() = %+v, want %+v", got, want)
+	}
+	if got.Failures() != 3 {
+		t.Errorf("Failures() = %d, want 3", got.Failures())
+	}
+}
+
+func TestCheckResultReport(t *testing.T) {
+	result := CheckResult{
+		MissingFiles:  []FileStructure{{Destination: "a.txt", Content: "a"}},
+		UpToDateFiles: []FileStructure{{Destination: "b.txt", Content: "b"}},
+	}
+
+	got := result.Report("project1", "out")
+	if got.Command != "check" {
+		t.Errorf("Command = %s, want check", got.Command)
+	}
+	if len(got.Files) != 2 || got.Files[0].Status != "missing" || got.Files[1].Status != "up-to-date" {
+		t.Errorf("Files = %+v, want missing a.txt and up-to-date b.txt", got.Files)
+	}
+	if got.Failures() != 1 {
+		t.Errorf("Failures() = %d, want 1", got.Failures())
+	}
+}
+
+func TestFormatReports(t *testing.T) {
+	reports := []Report{{
+		Command:   "check",
+		Project:   "project1",
+		OutputDir: "out",
+		Files: []FileReport{
+			{Status: "outdated", Destination: "a.txt", SourceKind: SourceKindContent, Failed: true},
+			{Status: "up-to-date", Destination: "b.txt", SourceKind: SourceKindContent},
+		},
+	}}
+
+	t.Run("JSON", func(t *testing.T) {
+		output, err := formatReports(reports, FormatJSON)
+		if err != nil {
+			t.Fatalf("formatReports() error = %v", err)
+		}
+		var got []Report
+		if err := json.Unmarshal([]byte(output), &got); err != nil {
+			t.Fatalf("Failed to parse JSON output: %v", err)
+		}
+		if !reflect.DeepEqual(got, reports) {
+			t.Errorf("JSON round trip = %+v, want %+v", got, reports)
+		}
+	})
+
+	t.Run("YAML", func(t *testing.T) {
+		output, err := formatReports(reports, FormatYAML)
+		if err != nil {
+			t.Fatalf("formatReports() error = %v", err)
+		}
+		var got []Report
+		if err := yaml.Unmarshal([]byte(output), &got); err != nil {
+			t.Fatalf("Failed to parse YAML output: %v", err)
+		}
+		if !reflect.DeepEqual(got, reports) {
+			t.Errorf("YAML round trip = %+v, want %+v", got, reports)
+		}
+	})
+
+	t.Run("JUnit", func(t *testing.T) {
+		output, err := formatReports(reports, FormatJUnit)
+		if err != nil {
+			t.Fatalf("formatReports() error = %v", err)
+		}
+		var got junitTestSuites
+		if err := xml.Unmarshal([]byte(output), &got); err != nil {
+			t.Fatalf("Failed to parse JUnit output: %v", err)
+		}
+		if got.Tests != 2 || got.Failures != 1 {
+			t.Errorf("tests = %d, failures = %d, want 2 and 1", got.Tests, got.Failures)
+		}
+		if len(got.Suites) != 1 || got.Suites[0].TestCases[0].Failure == nil || got.Suites[0].TestCases[1].Failure != nil {
+			t.Errorf("Unexpected test suites: %+v", got.Suites)
+		}
+	})
+
+	t.Run("SARIF", func(t *testing.T) {
+		output, err := formatReports(reports, FormatSARIF)
+		if err != nil {
+			t.Fatalf("formatReports() error = %v", err)
+		}
+		var got sarifLog
+		if err := json.Unmarshal([]byte(output), &got); err != nil {
+			t.Fatalf("Failed to parse SARIF output: %v", err)
+		}
+		if got.Version != "2.1.0" || len(got.Runs) != 1 {
+			t.Fatalf("Unexpected SARIF log: %+v", got)
+		}
+		results := got.Runs[0].Results
+		if len(results) != 1 || results[0].RuleID != "outdated" {
+			t.Fatalf("results = %+v, want a single outdated result", results)
+		}
+		if uri := results[0].Locations[0].PhysicalLocation.ArtifactLocation.URI; uri != "out/a.txt" {
+			t.Errorf("uri = %s, want out/a.txt", uri)
+		}
+	})
+
+	t.Run("Unsupported Format", func(t *testing.T) {
+		if _, err := formatReports(reports, "csv"); err == nil {
+			t.Error("formatReports() expected error for unsupported format")
+		}
+	})
+}
+
+func TestFormatReportsRedactsSecrets(t *testing.T) {
+	secret := `tok&en<1234>"\`
+	cfg := ConfigFile{}
+	cfg.addSecret(secret)
+
+	report := Report{
+		Command:   "diff",
+		Project:   "project1",
+		OutputDir: "out",
+		Files: []FileReport{
+			{Status: "new", Destination: "token.txt", Patch: unifiedDiff("a/token.txt", "b/token.txt", nil, []byte("token: "+secret+"\n")), Failed: true},
+		},
+	}
+
+	tests := []struct {
+		format    string
+		wantPatch bool
+	}{
+		{format: FormatJSON, wantPatch: true},
+		{format: FormatYAML, wantPatch: true},
+		{format: FormatJUnit, wantPatch: true},
+		{format: FormatSARIF},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			output, err := formatReports([]Report{report.redact(cfg.redact)}, tt.format)
+			if err != nil {
+				t.Fatalf("formatReports() error = %v", err)
+			}
+			if strings.Contains(output, "1234") {
+				t.Errorf("formatReports() output contains secret:\n%s", output)
+			}
+			if tt.wantPatch && !strings.Contains(output, "token: ***") {
+				t.Errorf("formatReports() output = %s, want redacted patch", output)
+			}
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Types that can be declared for a value in a group schema.
+const (
+	schemaTypeString  = "string"
+	schemaTypeNumber  = "number"
+	schemaTypeInteger = "integer"
+	schemaTypeBoolean = "boolean"
+	schemaTypeArray   = "array"
+	schemaTypeObject  = "object"
+)
+
+// schemaTypes lists all types that can be declared for a value.
+var schemaTypes = []string{schemaTypeString, schemaTypeNumber, schemaTypeInteger, schemaTypeBoolean, schemaTypeArray, schemaTypeObject}
+
+// ValuesSchema declares the values a template group expects, following a subset of JSON Schema.
+type ValuesSchema struct {
+	Required   []string                  `yaml:"required"`
+	Properties map[string]SchemaProperty `yaml:"properties"`
+}
+
+// SchemaProperty declares a single value of a template group.
+// Values of type object can declare nested properties.
+type SchemaProperty struct {
+	Type        string                    `yaml:"type"`
+	Enum        []any                     `yaml:"enum"`
+	Default     any                       `yaml:"default"`
+	Description string                    `yaml:"description"`
+	Required    []string                  `yaml:"required"`
+	Properties  map[string]SchemaProperty `yaml:"properties"`
+}
+
+// Defaults returns the default values declared by the schema, including those of nested properties.
+func (s ValuesSchema) Defaults() map[string]any {
+	return schemaDefaults(s.Properties)
+}
+
+// schemaDefaults returns the default values of properties.
+func schemaDefaults(properties map[string]SchemaProperty) map[string]any {
+	var defaults map[string]any
+	for name, property := range properties {
+		value := property.Default
+		if nested := schemaDefaults(property.Properties); nested != nil {
+			if m, ok := value.(map[string]any); ok {
+				value = mergeValues(nested, m)
+			} else if value == nil {
+				value = nested
+			}
+		}
+		if value == nil {
+			continue
+		}
+		if defaults == nil {
+			defaults = make(map[string]any)
+		}
+		defaults[name] = value
+	}
+	return defaults
+}
+
+// Validate checks values against the schema and returns all violations.
+func (s ValuesSchema) Validate(values map[string]any) []error {
+	return validateProperties("", s.Required, s.Properties, values)
+}
+
+// validateProperties checks the values of an object against the declared properties.
+// Keys that are not declared are permitted, so that groups can share values.
+func validateProperties(prefix string, required []string, properties map[string]SchemaProperty, values map[string]any) []error {
+	var errs []error
+	for _, name := range required {
+		if _, exists := values[name]; !exists {
+			msg := fmt.Sprintf("value %s is required", prefix+name)
+			if description := properties[name].Description; description != "" {
+				msg += fmt.Sprintf(" (%s)", description)
+			}
+			errs = append(errs, errors.New(msg))
+		}
+	}
+
+	for _, name := range sortedKeys(properties) {
+		value, exists := values[name]
+		if !exists {
+			continue
+		}
+		errs = append(errs, properties[name].validate(prefix+name, value)...)
+	}
+	return errs
+}
+
+// validate checks a single value against the property.
+func (p SchemaProperty) validate(path string, value any) []error {
+	if p.Type != "" && !matchesSchemaType(p.Type, value) {
+		return []error{fmt.Errorf("value %s must be of type %s, got %s", path, p.Type, schemaTypeOf(value))}
+	}
+	if len(p.Enum) > 0 && !slices.ContainsFunc(p.Enum, func(allowed any) bool { return equalSchemaValues(allowed, value) }) {
+		return []error{fmt.Errorf("value %s must be one of %s", path, formatEnum(p.Enum))}
+	}
+	if len(p.Required) == 0 && len(p.Properties) == 0 {
+		return nil
+	}
+	nested, ok := value.(map[string]any)
+	if !ok {
+		return []error{fmt.Errorf("value %s must be of type %s, got %s", path, schemaTypeObject, schemaTypeOf(value))}
+	}
+	return validateProperties(path+".", p.Required, p.Properties, nested)
+}
+
+// check reports problems of the schema itself, such as unknown types or defaults that violate the schema.
+func (s ValuesSchema) check() []error {
+	return checkProperties("", s.Required, s.Properties)
+}
+
+// checkProperties reports problems of the declared properties of an object.
+func checkProperties(prefix string, required []string, properties map[string]SchemaProperty) []error {
+	var errs []error
+	for _, name := range required {
+		if name == "" {
+			errs = append(errs, fmt.Errorf("required value of %s must not be empty", strings.TrimSuffix(prefix, ".")))
+		}
+	}
+	for _, name := range sortedKeys(properties) {
+		property := properties[name]
+		path := prefix + name
+		if property.Type != "" && !slices.Contains(schemaTypes, property.Type) {
+			errs = append(errs, fmt.Errorf("value %s has unknown type %s, expected one of %s", path, property.Type, strings.Join(schemaTypes, ", ")))
+			continue
+		}
+		if property.Default != nil {
+			for _, err := range property.validate(path, property.Default) {
+				errs = append(errs, fmt.Errorf("invalid default: %w", err))
+			}
+		}
+		errs = append(errs, checkProperties(path+".", property.Required, property.Properties)...)
+	}
+	return errs
+}
+
+// matchesSchemaType reports whether a decoded value is of the given schema type.
+func matchesSchemaType(schemaType string, value any) bool {
+	actual := schemaTypeOf(value)
+	switch schemaType {
+	case schemaTypeNumber:
+		return actual == schemaTypeNumber || actual == schemaTypeInteger
+	case schemaTypeInteger:
+		if f, ok := value.(float64); ok {
+			return f == float64(int64(f))
+		}
+		return actual == schemaTypeInteger
+	default:
+		return actual == schemaType
+	}
+}
+
+// schemaTypeOf returns the schema type of a decoded value.
+func schemaTypeOf(value any) string {
+	if value == nil {
+		return "null"
+	}
+	switch reflect.ValueOf(value).Kind() {
+	case reflect.String:
+		return schemaTypeString
+	case reflect.Bool:
+		return schemaTypeBoolean
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return schemaTypeInteger
+	case reflect.Float32, reflect.Float64:
+		return schemaTypeNumber
+	case reflect.Slice, reflect.Array:
+		return schemaTypeArray
+	case reflect.Map:
+		return schemaTypeObject
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+// equalSchemaValues reports whether two decoded values are equal, comparing numbers by their value.
+func equalSchemaValues(a, b any) bool {
+	if x, ok := toFloat(a); ok {
+		y, ok := toFloat(b)
+		return ok && x == y
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// toFloat converts a numeric value to a float64.
+func toFloat(value any) (float64, bool) {
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	default:
+		return 0, false
+	}
+}
+
+// formatEnum formats the allowed values of a property for error messages.
+func formatEnum(enum []any) string {
+	allowed := make([]string, 0, len(enum))
+	for _, value := range enum {
+		allowed = append(allowed, fmt.Sprintf("%q", fmt.Sprint(value)))
+	}
+	return strings.Join(allowed, ", ")
+}
+
+// sortedKeys returns the keys of a map in sorted order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
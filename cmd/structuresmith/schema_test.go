@@ -0,0 +1,262 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValuesSchemaValidate(t *testing.T) {
+	schema := ValuesSchema{
+		Required: []string{"golangciVersion", "image"},
+		Properties: map[string]SchemaProperty{
+			"golangciVersion": {Type: "string", Description: "version of golangci-lint"},
+			"replicas":        {Type: "integer"},
+			"ratio":           {Type: "number"},
+			"enableDocker":    {Type: "boolean"},
+			"platforms":       {Type: "array"},
+			"license":         {Type: "string", Enum: []any{"MIT", "Apache-2.0"}},
+			"port":            {Enum: []any{80, 443}},
+			"image": {
+				Type:       "object",
+				Required:   []string{"name"},
+				Properties: map[string]SchemaProperty{"tag": {Type: "string"}},
+			},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		values     map[string]any
+		wantErrors []string
+	}{
+		{
+			name: "Valid Values",
+			values: map[string]any{
+				"golangciVersion": "v1.59.0",
+				"replicas":        3,
+				"ratio":           1,
+				"enableDocker":    true,
+				"platforms":       []any{"linux"},
+				"license":         "MIT",
+				"port":            443.0,
+				"image":           map[string]any{"name": "app", "tag": "v1"},
+				"undeclared":      "permitted",
+			},
+		},
+		{
+			name:   "Integral Float As Integer",
+			values: map[string]any{"golangciVersion": "v1", "image": map[string]any{"name": "app"}, "replicas": 2.0},
+		},
+		{
+			name:       "Missing Required Values",
+			values:     map[string]any{},
+			wantErrors: []string{"value golangciVersion is required (version of golangci-lint)", "value image is required"},
+		},
+		{
+			name: "All Violations",
+			values: map[string]any{
+				"golangciVersion": 1.59,
+				"replicas":        "three",
+				"enableDocker":    "yes",
+				"license":         "GPL",
+				"port":            8080,
+				"image":           map[string]any{"tag": 1},
+			},
+			wantErrors: []string{
+				`value enableDocker must be of type boolean, got string`,
+				`value golangciVersion must be of type string, got number`,
+				`value image.name is required`,
+				`value image.tag must be of type string, got integer`,
+				`value license must be one of "MIT", "Apache-2.0"`,
+				`value port must be one of "80", "443"`,
+				`value replicas must be of type integer, got string`,
+			},
+		},
+		{
+			name:       "Object Expected",
+			values:     map[string]any{"golangciVersion": "v1", "image": "app"},
+			wantErrors: []string{"value image must be of type object, got string"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, err := range schema.Validate(tt.values) {
+				got = append(got, err.Error())
+			}
+			if !reflect.DeepEqual(got, tt.wantErrors) {
+				t.Errorf("Validate() = %q, want %q", got, tt.wantErrors)
+			}
+		})
+	}
+}
+
+func TestValuesSchemaDefaults(t *testing.T) {
+	schema := ValuesSchema{Properties: map[string]SchemaProperty{
+		"replicas": {Type: "integer", Default: 1},
+		"license":  {Type: "string"},
+		"image": {Type: "object", Default: map[string]any{"name": "app"}, Properties: map[string]SchemaProperty{
+			"name": {Default: "default"},
+			"tag":  {Default: "latest"},
+		}},
+	}}
+
+	want := map[string]any{"replicas": 1, "image": map[string]any{"name": "app", "tag": "latest"}}
+	if got := schema.Defaults(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Defaults() = %v, want %v", got, want)
+	}
+	if got := (ValuesSchema{}).Defaults(); got != nil {
+		t.Errorf("Defaults() = %v, want nil", got)
+	}
+}
+
+func TestValidateGroupSchemas(t *testing.T) {
+	groups := map[string][]FileStructure{
+		"golang": {{Destination: ".golangci.yml", Content: "x"}},
+		"ci":     {{GroupName: "golang", Values: map[string]any{"golangciVersion": "v1.59.0"}}},
+	}
+	schemas := map[string]ValuesSchema{
+		"golang": {
+			Required: []string{"golangciVersion"},
+			Properties: map[string]SchemaProperty{
+				"golangciVersion": {Type: "string"},
+				"goVersion":       {Type: "string", Default: "1.23"},
+			},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		config     ConfigFile
+		overrides  map[string]any
+		wantErrors []string
+	}{
+		{
+			name: "Values From Globals And Group Reference",
+			config: ConfigFile{
+				Globals:        map[string]any{"golangciVersion": "v1.59.0"},
+				TemplateGroups: groups,
+				GroupSchemas:   schemas,
+				Projects:       []ProjectConfig{{Name: "project1", Groups: []TemplateGroupRef{{GroupName: "golang", Values: map[string]any{"goVersion": "1.22"}}}}},
+			},
+		},
+		{
+			name: "Values From Overrides",
+			config: ConfigFile{
+				TemplateGroups: groups,
+				GroupSchemas:   schemas,
+				Projects:       []ProjectConfig{{Name: "project1", Groups: []TemplateGroupRef{{GroupName: "golang", Values: map[string]any{"goVersion": 1.22}}}}},
+			},
+			overrides:  map[string]any{"golangciVersion": "v1.59.0"},
+			wantErrors: []string{"project project1: template group golang: value goVersion must be of type string, got number"},
+		},
+		{
+			name: "Invalid Override",
+			config: ConfigFile{
+				Globals:        map[string]any{"golangciVersion": "v1.59.0"},
+				TemplateGroups: groups,
+				GroupSchemas:   schemas,
+				Projects:       []ProjectConfig{{Name: "project1", Groups: []TemplateGroupRef{{GroupName: "golang"}}}},
+			},
+			overrides:  map[string]any{"golangciVersion": 2},
+			wantErrors: []string{"project project1: template group golang: value golangciVersion must be of type string, got integer"},
+		},
+		{
+			name: "Values From Group Include",
+			config: ConfigFile{
+				TemplateGroups: groups,
+				GroupSchemas:   schemas,
+				Projects:       []ProjectConfig{{Name: "project1", Groups: []TemplateGroupRef{{GroupName: "ci"}}}},
+			},
+		},
+		{
+			name: "Violations In All Projects",
+			config: ConfigFile{
+				TemplateGroups: groups,
+				GroupSchemas:   schemas,
+				Projects: []ProjectConfig{
+					{Name: "project1", Groups: []TemplateGroupRef{{GroupName: "golang"}}},
+					{Name: "project2", Values: map[string]any{"goVersion": 1.23}, Groups: []TemplateGroupRef{{GroupName: "golang"}}},
+					{Name: "base", Abstract: true, Groups: []TemplateGroupRef{{GroupName: "golang"}}},
+				},
+			},
+			wantErrors: []string{
+				"project project1: template group golang: value golangciVersion is required",
+				"project project2: template group golang: value golangciVersion is required",
+				"project project2: template group golang: value goVersion must be of type string, got number",
+			},
+		},
+		{
+			name: "Excluded Group Is Not Validated",
+			config: ConfigFile{
+				TemplateGroups: groups,
+				GroupSchemas:   schemas,
+				Projects:       []ProjectConfig{{Name: "project1", Groups: []TemplateGroupRef{{GroupName: "golang", When: "false"}}}},
+			},
+		},
+		{
+			name: "Invalid Schemas",
+			config: ConfigFile{
+				TemplateGroups: groups,
+				GroupSchemas: map[string]ValuesSchema{
+					"golang":  {Properties: map[string]SchemaProperty{"a": {Type: "text"}, "b": {Type: "integer", Default: "one"}}},
+					"missing": {},
+				},
+			},
+			wantErrors: []string{
+				"schema of template group golang: value a has unknown type text, expected one of string, number, integer, boolean, array, object",
+				"schema of template group golang: invalid default: value b must be of type integer, got string",
+				"schema declared for non-existent template group: missing",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.validateGroupSchemas()
+			if err == nil {
+				app := &Structuresmith{Overrides: tt.overrides}
+				err = tt.config.validateGroupValues(func(p Project) valueScope { return app.projectScope(p, tt.config) }, false)
+			}
+			var got []string
+			if err != nil {
+				got = strings.Split(err.Error(), "\n")
+			}
+			if !reflect.DeepEqual(got, tt.wantErrors) {
+				t.Errorf("validateGroupSchemas() and validateGroupValues() = %q, want %q", got, tt.wantErrors)
+			}
+		})
+	}
+}
+
+func TestProcessProjectSchemaDefaults(t *testing.T) {
+	app := &Structuresmith{}
+	cfg := ConfigFile{
+		TemplateGroups: map[string][]FileStructure{"golang": {{Destination: "go.mod", Content: "go {{ .goVersion }}"}}},
+		GroupSchemas: map[string]ValuesSchema{"golang": {Properties: map[string]SchemaProperty{
+			"goVersion": {Default: "1.23"},
+			"module":    {Default: "example.com/default"},
+		}}},
+	}
+	project := Project{
+		Name:   "project1",
+		Values: map[string]any{"module": "example.com/project1"},
+		Groups: []TemplateGroupRef{{GroupName: "golang"}},
+	}
+
+	files, err := app.processProject(project, cfg)
+	if err != nil {
+		t.Fatalf("processProject() error = %v", err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("processProject() returned %d files, want 1", len(files))
+	}
+	if got := files[0].Values["goVersion"]; got != "1.23" {
+		t.Errorf("goVersion = %v, want 1.23", got)
+	}
+	if got := files[0].Values["module"]; got != "example.com/project1" {
+		t.Errorf("module = %v, want example.com/project1", got)
+	}
+}
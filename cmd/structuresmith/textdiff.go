@@ -0,0 +1,184 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContextLines is the number of unchanged lines shown around each change in a unified diff.
+const diffContextLines = 3
+
+// maxDiffCells limits the size of the table used to compute a line diff.
+// Larger inputs are reported as a full replacement instead.
+const maxDiffCells = 1 << 22
+
+// diffOp is a single line operation of an edit script.
+type diffOp struct {
+	kind byte // ' ' for unchanged, '-' for removed and '+' for added lines.
+	text string
+}
+
+// unifiedDiff returns a unified diff that transforms from into to.
+// It returns an empty string if both are identical. Binary content is reported as a single line.
+func unifiedDiff(fromName, toName string, from, to []byte) string {
+	if string(from) == string(to) {
+		return ""
+	}
+	if isBinary(from) || isBinary(to) {
+		return fmt.Sprintf("Binary files %s and %s differ\n", fromName, toName)
+	}
+
+	ops := diffLines(splitLines(string(from)), splitLines(string(to)))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", fromName, toName)
+	for _, h := range buildHunks(ops, diffContextLines) {
+		b.WriteString(h)
+	}
+	return b.String()
+}
+
+// splitLines splits text into lines, keeping the trailing newline of each line.
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines computes an edit script from a to b based on their longest common subsequence.
+func diffLines(a, b []string) []diffOp {
+	// Strip common prefix and suffix to keep the table small
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var ops []diffOp
+	for _, line := range a[:prefix] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	ops = append(ops, diffMiddle(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	return ops
+}
+
+// diffMiddle computes the edit script for lines that differ at both ends.
+func diffMiddle(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+	var ops []diffOp
+
+	if (n+1)*(m+1) > maxDiffCells {
+		for _, line := range a {
+			ops = append(ops, diffOp{'-', line})
+		}
+		for _, line := range b {
+			ops = append(ops, diffOp{'+', line})
+		}
+		return ops
+	}
+
+	// lcs[i][j] holds the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		ops = append(ops, diffOp{'-', a[i]})
+	}
+	for ; j < m; j++ {
+		ops = append(ops, diffOp{'+', b[j]})
+	}
+	return ops
+}
+
+// buildHunks groups an edit script into unified diff hunks with the given number of context lines.
+func buildHunks(ops []diffOp, context int) []string {
+	// Determine the ranges of operations to show, merging overlapping ones
+	var ranges [][2]int
+	for k, op := range ops {
+		if op.kind == ' ' {
+			continue
+		}
+		start, end := max(k-context, 0), min(k+context+1, len(ops))
+		if len(ranges) > 0 && start <= ranges[len(ranges)-1][1] {
+			ranges[len(ranges)-1][1] = end
+			continue
+		}
+		ranges = append(ranges, [2]int{start, end})
+	}
+
+	// Line numbers in a and b before each operation
+	aLine, bLine := make([]int, len(ops)+1), make([]int, len(ops)+1)
+	for k, op := range ops {
+		aLine[k+1], bLine[k+1] = aLine[k], bLine[k]
+		if op.kind != '+' {
+			aLine[k+1]++
+		}
+		if op.kind != '-' {
+			bLine[k+1]++
+		}
+	}
+
+	hunks := make([]string, 0, len(ranges))
+	for _, r := range ranges {
+		var b strings.Builder
+		aCount, bCount := aLine[r[1]]-aLine[r[0]], bLine[r[1]]-bLine[r[0]]
+		fmt.Fprintf(&b, "@@ -%s +%s @@\n", hunkRange(aLine[r[0]], aCount), hunkRange(bLine[r[0]], bCount))
+		for _, op := range ops[r[0]:r[1]] {
+			b.WriteByte(op.kind)
+			b.WriteString(op.text)
+			if !strings.HasSuffix(op.text, "\n") {
+				b.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		hunks = append(hunks, b.String())
+	}
+	return hunks
+}
+
+// hunkRange formats the start line and line count of a hunk header.
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		from string
+		to   string
+		want string
+	}{
+		{
+			name: "Identical Content",
+			from: "a\nb\n",
+			to:   "a\nb\n",
+			want: "",
+		},
+		{
+			name: "Changed Line",
+			from: "a\nb\nc\n",
+			to:   "a\nx\nc\n",
+			want: "--- a/f\n+++ b/f\n@@ -1,3 +1,3 @@\n a\n-b\n+x\n c\n",
+		},
+		{
+			name: "Added Lines To Empty File",
+			from: "",
+			to:   "a\nb\n",
+			want: "--- a/f\n+++ b/f\n@@ -0,0 +1,2 @@\n+a\n+b\n",
+		},
+		{
+			name: "Removed Line",
+			from: "a\nb\n",
+			to:   "a\n",
+			want: "--- a/f\n+++ b/f\n@@ -1,2 +1 @@\n a\n-b\n",
+		},
+		{
+			name: "Missing Newline At End Of File",
+			from: "a\n",
+			to:   "a",
+			want: "--- a/f\n+++ b/f\n@@ -1 +1 @@\n-a\n+a\n\\ No newline at end of file\n",
+		},
+		{
+			name: "Separate Hunks",
+			from: "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+			to:   "x\n2\n3\n4\n5\n6\n7\n8\n9\ny\n",
+			want: "--- a/f\n+++ b/f\n@@ -1,4 +1,4 @@\n-1\n+x\n 2\n 3\n 4\n@@ -7,4 +7,4 @@\n 7\n 8\n 9\n-10\n+y\n",
+		},
+		{
+			name: "Changed Binary Content",
+			from: "\x89PNG\x00\x01",
+			to:   "\x89PNG\x00\x02",
+			want: "Binary files a/f and b/f differ\n",
+		},
+		{
+			name: "Text Replaced By Binary Content",
+			from: "a\n",
+			to:   "\x00\x01",
+			want: "Binary files a/f and b/f differ\n",
+		},
+		{
+			name: "Identical Binary Content",
+			from: "\x00\x01",
+			to:   "\x00\x01",
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := unifiedDiff("a/f", "b/f", []byte(tt.from), []byte(tt.to))
+			if got != tt.want {
+				t.Errorf("unifiedDiff() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		name string
+		a    []string
+		b    []string
+		want string
+	}{
+		{name: "Empty", a: nil, b: nil, want: ""},
+		{name: "Insert In Middle", a: []string{"a", "c"}, b: []string{"a", "b", "c"}, want: " a+b c"},
+		{name: "Delete In Middle", a: []string{"a", "b", "c"}, b: []string{"a", "c"}, want: " a-b c"},
+		{name: "Replace All", a: []string{"a"}, b: []string{"b"}, want: "-a+b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got strings.Builder
+			for _, op := range diffLines(tt.a, tt.b) {
+				got.WriteByte(op.kind)
+				got.WriteString(op.text)
+			}
+			if got.String() != tt.want {
+				t.Errorf("diffLines() = %q, want %q", got.String(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// maxSuggestionDistance is the maximum edit distance of a known key suggested for an unknown key.
+// Shorter keys allow fewer edits, so that unrelated keys are not suggested.
+const maxSuggestionDistance = 2
+
+// unknownKeys reports every key of a YAML document that does not correspond to a field of the given type.
+// Unknown keys are located at their position in the file and include a suggestion for near-miss keys.
+func unknownKeys(node *yaml.Node, t reflect.Type, filename string) ValidationErrors {
+	var errs ValidationErrors
+	var walk func(node *yaml.Node, t reflect.Type)
+	walk = func(node *yaml.Node, t reflect.Type) {
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		switch node.Kind {
+		case yaml.DocumentNode:
+			for _, child := range node.Content {
+				walk(child, t)
+			}
+			return
+		case yaml.AliasNode:
+			if node.Alias != nil {
+				walk(node.Alias, t)
+			}
+			return
+		}
+
+		switch t.Kind() {
+		case reflect.Struct:
+			if node.Kind != yaml.MappingNode {
+				return
+			}
+			fields := yamlFields(t)
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				key, value := node.Content[i], node.Content[i+1]
+				// Merge keys such as "<<: *defaults" contribute the keys of the merged mappings
+				if key.Tag == "!!merge" {
+					walk(value, t)
+					continue
+				}
+				field, known := fields[key.Value]
+				if !known {
+					errs = append(errs, ValidationError{
+						File:    filename,
+						Line:    key.Line,
+						Column:  key.Column,
+						Message: unknownKeyMessage(key.Value, fields),
+					})
+					continue
+				}
+				walk(value, field.Type)
+			}
+		case reflect.Map:
+			if node.Kind != yaml.MappingNode {
+				return
+			}
+			for i := 1; i < len(node.Content); i += 2 {
+				walk(node.Content[i], t.Elem())
+			}
+		case reflect.Slice, reflect.Array:
+			if node.Kind != yaml.SequenceNode {
+				return
+			}
+			for _, item := range node.Content {
+				walk(item, t.Elem())
+			}
+		}
+	}
+	walk(node, t)
+	return errs
+}
+
+// yamlFields returns the fields of a struct by the key they are decoded from.
+func yamlFields(t reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		switch name {
+		case "-":
+			continue
+		case "":
+			name = strings.ToLower(field.Name)
+		}
+		fields[name] = field
+	}
+	return fields
+}
+
+// unknownKeyMessage describes an unknown key, suggesting the closest known key if there is one.
+func unknownKeyMessage(key string, fields map[string]reflect.StructField) string {
+	msg := fmt.Sprintf("unknown key %q", key)
+	if suggestion := suggestKey(key, fields); suggestion != "" {
+		msg += fmt.Sprintf(", did you mean %q?", suggestion)
+	}
+	return msg
+}
+
+// suggestKey returns the known key closest to an unknown key, or an empty string if none is close enough.
+// Keys that only differ in case are always suggested.
+func suggestKey(key string, fields map[string]reflect.StructField) string {
+	suggestion, best := "", min(maxSuggestionDistance, len(key)/3)+1
+	for _, known := range sortedKeys(fields) {
+		distance := levenshtein(strings.ToLower(key), strings.ToLower(known))
+		if distance < best {
+			suggestion, best = known, distance
+		}
+	}
+	return suggestion
+}
+
+// levenshtein returns the number of single character edits needed to change a into b.
+func levenshtein(a, b string) int {
+	s, t := []rune(a), []rune(b)
+	prev := make([]int, len(t)+1)
+	curr := make([]int, len(t)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(s); i++ {
+		curr[0] = i
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(t)]
+}
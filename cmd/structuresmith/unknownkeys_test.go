@@ -0,0 +1,148 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestUnknownKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []string
+	}{
+		{
+			name: "Known Keys",
+			data: `globals:
+  anyKey: 1
+groupSchemas:
+  group1:
+    properties:
+      name: {type: string}
+templateGroups:
+  group1:
+    - destination: "README.md"
+      content: "readme"
+      values:
+        anyKey: 1
+      permissions: "0644"
+      overwrite: false
+projects:
+  - name: "project1"
+    groups:
+      - groupName: "group1"
+        values: {anyKey: 1}
+`,
+		},
+		{
+			name: "Misspelled Keys",
+			data: `templateGroups:
+  group1:
+    - destination: "README.md"
+      sourceURL: "https://example.com/README.md"
+      overwite: false
+      permission: "0644"
+projects:
+  - name: "project1"
+    groups:
+      - groupname: "group1"
+`,
+			want: []string{
+				`t.yml:4:7: unknown key "sourceURL", did you mean "sourceUrl"?`,
+				`t.yml:5:7: unknown key "overwite", did you mean "overwrite"?`,
+				`t.yml:6:7: unknown key "permission", did you mean "permissions"?`,
+				`t.yml:10:9: unknown key "groupname", did you mean "groupName"?`,
+			},
+		},
+		{
+			name: "Unrelated Key",
+			data: "projects:\n  - name: \"project1\"\n    colour: blue\n",
+			want: []string{`t.yml:3:5: unknown key "colour"`},
+		},
+		{
+			name: "Merge Keys",
+			data: `templateGroups:
+  group1:
+    - &readme
+      destination: "README.md"
+      content: "readme"
+    - <<: *readme
+      destination: "README.txt"
+      contnet: "typo"
+`,
+			want: []string{`t.yml:8:7: unknown key "contnet", did you mean "content"?`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var document yaml.Node
+			if err := yaml.Unmarshal([]byte(tt.data), &document); err != nil {
+				t.Fatalf("Failed to parse YAML: %v", err)
+			}
+			var got []string
+			for _, err := range unknownKeys(&document, reflect.TypeOf(ConfigFile{}), "t.yml") {
+				got = append(got, err.Error())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("unknownKeys() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "", b: "", want: 0},
+		{a: "overwrite", b: "overwrite", want: 0},
+		{a: "overwite", b: "overwrite", want: 1},
+		{a: "permission", b: "permissions", want: 1},
+		{a: "contnet", b: "content", want: 2},
+		{a: "", b: "raw", want: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+"/"+tt.b, func(t *testing.T) {
+			if got := levenshtein(tt.a, tt.b); got != tt.want {
+				t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateConfigReportsUnknownKeys(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "anvil.yml")
+	data := "projects:\n  - name: \"project1\"\n    files:\n      - destination: \"a\"\n        content: \"a\"\n        overwite: false\n  - name: \"project1\"\n"
+	if err := os.WriteFile(configPath, []byte(data), 0o644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	config, err := readConfig(configPath, "templates")
+	if err != nil {
+		t.Fatalf("readConfig() error = %v", err)
+	}
+
+	// Unknown keys are reported together with all other problems of the configuration
+	var errs ValidationErrors
+	if err := config.validateConfig(); !errors.As(err, &errs) {
+		t.Fatalf("validateConfig() error = %v, want ValidationErrors", err)
+	}
+	if len(errs) != 2 {
+		t.Fatalf("validateConfig() error = %v, want 2 problems", errs)
+	}
+	if errs[0].Line != 6 || errs[0].Column != 9 || errs[0].Message != `unknown key "overwite", did you mean "overwrite"?` {
+		t.Errorf("validateConfig() errs[0] = %v", errs[0])
+	}
+	if errs[1].Line != 7 || errs[1].Message != "duplicate repository name: project1" {
+		t.Errorf("validateConfig() errs[1] = %v", errs[1])
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Position is a location in the configuration file.
+type Position struct {
+	Line   int
+	Column int
+}
+
+// ValidationError is a problem found in the configuration, located in the configuration file if its position is known.
+type ValidationError struct {
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+// Error formats the problem compiler-style, e.g. "anvil.yml:42:7: duplicate repository name: example".
+func (e ValidationError) Error() string {
+	switch {
+	case e.File != "" && e.Line > 0:
+		return fmt.Sprintf("%s:%d:%d: %s", e.File, e.Line, e.Column, e.Message)
+	case e.File != "":
+		return fmt.Sprintf("%s: %s", e.File, e.Message)
+	default:
+		return e.Message
+	}
+}
+
+// ValidationErrors holds all problems found in the configuration.
+type ValidationErrors []ValidationError
+
+// Error formats one problem per line.
+func (e ValidationErrors) Error() string {
+	lines := make([]string, 0, len(e))
+	for _, err := range e {
+		lines = append(lines, err.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// err returns the problems as an error, or nil if there are none.
+func (e ValidationErrors) err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// validationResult is the machine-readable result of validating a configuration.
+type validationResult struct {
+	Valid  bool              `json:"valid"`
+	Errors []ValidationError `json:"errors"`
+}
+
+// formatValidationErrors serializes the problems found in a configuration as JSON.
+func formatValidationErrors(errs ValidationErrors) (string, error) {
+	result := validationResult{Valid: len(errs) == 0, Errors: errs}
+	if result.Errors == nil {
+		result.Errors = []ValidationError{}
+	}
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("error marshaling JSON validation result: %w", err)
+	}
+	return string(data) + "\n", nil
+}
+
+// errorAt returns a problem located at a path in the configuration, e.g. "projects[2].groups[0].groupName".
+// If the path is not found, the problem is located at the closest enclosing path.
+func (c *ConfigFile) errorAt(path, format string, args ...any) ValidationError {
+	e := ValidationError{File: c.filename, Message: fmt.Sprintf(format, args...)}
+	for path != "" {
+		if pos, found := c.positions[path]; found {
+			e.Line, e.Column = pos.Line, pos.Column
+			break
+		}
+		path = parentPath(path)
+	}
+	return e
+}
+
+// parentPath returns the path enclosing a path in the configuration.
+func parentPath(path string) string {
+	if strings.HasSuffix(path, "]") {
+		if i := strings.LastIndex(path, "["); i >= 0 {
+			return path[:i]
+		}
+	}
+	if i := strings.LastIndex(path, "."); i >= 0 {
+		return path[:i]
+	}
+	return ""
+}
+
+// indexPositions records the position of every key and list item of a YAML document by its path in the configuration.
+func indexPositions(node *yaml.Node) map[string]Position {
+	positions := make(map[string]Position)
+	var walk func(node *yaml.Node, path string)
+	walk = func(node *yaml.Node, path string) {
+		switch node.Kind {
+		case yaml.DocumentNode:
+			for _, child := range node.Content {
+				walk(child, path)
+			}
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				key, value := node.Content[i], node.Content[i+1]
+				childPath := key.Value
+				if path != "" {
+					childPath = path + "." + key.Value
+				}
+				positions[childPath] = Position{Line: key.Line, Column: key.Column}
+				walk(value, childPath)
+			}
+		case yaml.SequenceNode:
+			for i, item := range node.Content {
+				childPath := path + "[" + strconv.Itoa(i) + "]"
+				positions[childPath] = Position{Line: item.Line, Column: item.Column}
+				walk(item, childPath)
+			}
+		case yaml.AliasNode:
+			if node.Alias != nil {
+				walk(node.Alias, path)
+			}
+		}
+	}
+	walk(node, "")
+	return positions
+}
+
+// displayPath returns the path of a file relative to the working directory, if it is located inside of it.
+func displayPath(filename string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return filename
+	}
+	if rel, err := filepath.Rel(wd, filename); err == nil && filepath.IsLocal(rel) {
+		return rel
+	}
+	return filename
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestValidationErrorError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  ValidationError
+		want string
+	}{
+		{name: "With Position", err: ValidationError{File: "anvil.yml", Line: 42, Column: 7, Message: "problem"}, want: "anvil.yml:42:7: problem"},
+		{name: "Without Position", err: ValidationError{File: "anvil.yml", Message: "problem"}, want: "anvil.yml: problem"},
+		{name: "Without File", err: ValidationError{Message: "problem"}, want: "problem"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.err.Error(); got != tt.want {
+				t.Errorf("Error() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	errs := ValidationErrors{{Message: "first"}, {File: "anvil.yml", Line: 1, Column: 1, Message: "second"}}
+	if got, want := errs.Error(), "first\nanvil.yml:1:1: second"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+	if err := ValidationErrors(nil).err(); err != nil {
+		t.Errorf("err() = %v, want nil", err)
+	}
+}
+
+func TestIndexPositions(t *testing.T) {
+	data := `projects:
+  - name: "project1"
+    groups:
+      - groupName: "group1"
+templateGroups:
+  group1:
+    - destination: "README.md"
+`
+	var document yaml.Node
+	if err := yaml.Unmarshal([]byte(data), &document); err != nil {
+		t.Fatalf("Failed to parse YAML: %v", err)
+	}
+
+	positions := indexPositions(&document)
+	want := map[string]Position{
+		"projects":                        {Line: 1, Column: 1},
+		"projects[0]":                     {Line: 2, Column: 5},
+		"projects[0].name":                {Line: 2, Column: 5},
+		"projects[0].groups[0].groupName": {Line: 4, Column: 9},
+		"templateGroups.group1[0]":        {Line: 7, Column: 7},
+	}
+	for path, pos := range want {
+		if got := positions[path]; got != pos {
+			t.Errorf("position of %s = %v, want %v", path, got, pos)
+		}
+	}
+}
+
+func TestErrorAt(t *testing.T) {
+	config := ConfigFile{
+		filename:  "anvil.yml",
+		positions: map[string]Position{"projects[0]": {Line: 2, Column: 5}},
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "projects[0]", want: "anvil.yml:2:5: problem"},
+		{path: "projects[0].groups[1].values", want: "anvil.yml:2:5: problem"},
+		{path: "templateGroups.group1", want: "anvil.yml: problem"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := config.errorAt(tt.path, "%s", "problem").Error(); got != tt.want {
+				t.Errorf("errorAt() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateConfigReportsAllErrors(t *testing.T) {
+	tmpDir := t.TempDir()
+	data := `templateGroups:
+  group1:
+    - destination: "README.md"
+      content: "readme"
+    - groupName: "missing"
+projects:
+  - name: "project1"
+    delims: ["[["]
+    groups:
+      - groupName: "unknown"
+  - name: "project1"
+    extends: "ghost"
+`
+	configPath := filepath.Join(tmpDir, "anvil.yml")
+	if err := os.WriteFile(configPath, []byte(data), 0o644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	config, err := readConfig(configPath, tmpDir)
+	if err != nil {
+		t.Fatalf("readConfig() error = %v", err)
+	}
+
+	err = config.validateConfig()
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("validateConfig() error = %v, want ValidationErrors", err)
+	}
+
+	type located struct {
+		Line, Column int
+		Message      string
+	}
+	var got []located
+	for _, e := range errs {
+		if e.File != config.filename {
+			t.Errorf("File = %q, want %q", e.File, config.filename)
+		}
+		got = append(got, located{e.Line, e.Column, e.Message})
+	}
+	want := []located{
+		{5, 7, "template group group1 includes non-existent group: missing"},
+		{8, 5, `invalid delims ["[["] for project project1: expected a left and a right delimiter`},
+		{10, 9, "repository project1 refers to non-existent group: unknown"},
+		{11, 5, "duplicate repository name: project1"},
+		{12, 5, "project project1 extends non-existent project: ghost"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("validateConfig() = %v, want %v", got, want)
+	}
+}
+
+func TestFormatValidationErrors(t *testing.T) {
+	output, err := formatValidationErrors(ValidationErrors{{File: "anvil.yml", Line: 3, Column: 5, Message: "problem"}})
+	if err != nil {
+		t.Fatalf("formatValidationErrors() error = %v", err)
+	}
+	var result validationResult
+	if err := json.Unmarshal([]byte(output), &result); err != nil {
+		t.Fatalf("Failed to parse JSON: %v", err)
+	}
+	want := validationResult{Errors: []ValidationError{{File: "anvil.yml", Line: 3, Column: 5, Message: "problem"}}}
+	if !reflect.DeepEqual(result, want) {
+		t.Errorf("formatValidationErrors() = %+v, want %+v", result, want)
+	}
+
+	output, err = formatValidationErrors(nil)
+	if err != nil {
+		t.Fatalf("formatValidationErrors() error = %v", err)
+	}
+	if want := "{\n  \"valid\": true,\n  \"errors\": []\n}\n"; output != want {
+		t.Errorf("formatValidationErrors() = %q, want %q", output, want)
+	}
+}
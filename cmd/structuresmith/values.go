@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// readValuesFile reads values from a YAML or JSON file.
+func readValuesFile(filename string) (map[string]any, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read values file: %w", err)
+	}
+
+	var values map[string]any
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal values file %s: %w", filename, err)
+	}
+	return values, nil
+}
+
+// loadOverrides merges values files and --set expressions passed on the command line into a single map.
+// Later files take precedence over earlier ones, and --set expressions take precedence over all files.
+func loadOverrides(valuesFiles, setValues []string) (map[string]any, error) {
+	if len(valuesFiles) == 0 && len(setValues) == 0 {
+		return nil, nil
+	}
+
+	var overrides map[string]any
+	for _, filename := range valuesFiles {
+		values, err := readValuesFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		overrides = mergeValues(overrides, values)
+	}
+
+	for _, expr := range setValues {
+		values, err := parseSetValue(expr)
+		if err != nil {
+			return nil, err
+		}
+		overrides = mergeValues(overrides, values)
+	}
+	return overrides, nil
+}
+
+// parseSetValue parses a --set expression such as "image.tag=1.2.3" into nested values.
+// The values "true" and "false" are parsed as booleans and integers as numbers; everything else is kept as a string.
+func parseSetValue(expr string) (map[string]any, error) {
+	keyPath, raw, found := strings.Cut(expr, "=")
+	if !found {
+		return nil, fmt.Errorf("invalid --set expression %q: expected key=value", expr)
+	}
+
+	keys := strings.Split(keyPath, ".")
+	for _, key := range keys {
+		if key == "" {
+			return nil, fmt.Errorf("invalid --set expression %q: empty key", expr)
+		}
+	}
+
+	var value any = raw
+	switch raw {
+	case "true":
+		value = true
+	case "false":
+		value = false
+	default:
+		if i, err := strconv.Atoi(raw); err == nil {
+			value = i
+		}
+	}
+
+	// Build the nested values from the innermost key outwards
+	for i := len(keys) - 1; i >= 0; i-- {
+		value = map[string]any{keys[i]: value}
+	}
+	return value.(map[string]any), nil
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseSetValue(t *testing.T) {
+	tests := []struct {
+		expr    string
+		want    map[string]any
+		wantErr bool
+	}{
+		{expr: "version=1.2.3", want: map[string]any{"version": "1.2.3"}},
+		{expr: "image.tag=latest", want: map[string]any{"image": map[string]any{"tag": "latest"}}},
+		{expr: "enableDocker=true", want: map[string]any{"enableDocker": true}},
+		{expr: "enableDocker=false", want: map[string]any{"enableDocker": false}},
+		{expr: "replicas=3", want: map[string]any{"replicas": 3}},
+		{expr: "list=a,b", want: map[string]any{"list": "a,b"}},
+		{expr: "token=abc=def", want: map[string]any{"token": "abc=def"}},
+		{expr: "empty=", want: map[string]any{"empty": ""}},
+		{expr: "missingValue", wantErr: true},
+		{expr: "=value", wantErr: true},
+		{expr: "image..tag=1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := parseSetValue(tt.expr)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseSetValue() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseSetValue() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadOverrides(t *testing.T) {
+	tmpDir := t.TempDir()
+	first := filepath.Join(tmpDir, "first.yml")
+	second := filepath.Join(tmpDir, "second.json")
+	if err := os.WriteFile(first, []byte("image:\n  name: app\n  tag: v1\nreplicas: 1\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write values file: %v", err)
+	}
+	if err := os.WriteFile(second, []byte(`{"image": {"tag": "v2"}}`), 0o644); err != nil {
+		t.Fatalf("Failed to write values file: %v", err)
+	}
+
+	got, err := loadOverrides([]string{first, second}, []string{"image.tag=v3", "debug=true"})
+	if err != nil {
+		t.Fatalf("loadOverrides() error = %v", err)
+	}
+	want := map[string]any{
+		"image":    map[string]any{"name": "app", "tag": "v3"},
+		"replicas": 1,
+		"debug":    true,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("loadOverrides() = %v, want %v", got, want)
+	}
+
+	if got, err := loadOverrides(nil, nil); err != nil || got != nil {
+		t.Errorf("loadOverrides() = %v, %v, want no overrides", got, err)
+	}
+	if _, err := loadOverrides([]string{filepath.Join(tmpDir, "missing.yml")}, nil); err == nil {
+		t.Error("loadOverrides() expected error for missing values file")
+	}
+	if _, err := loadOverrides(nil, []string{"invalid"}); err == nil {
+		t.Error("loadOverrides() expected error for invalid --set expression")
+	}
+}
+
+func TestReadConfigValuesFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"anvil.yml": `projects:
+  - name: "project1"
+    values:
+      Author: "Inline"
+      Year: 2020
+    valuesFiles:
+      - values/common.yml
+      - values/project1.json
+`,
+		"values/common.yml":    "Year: 2024\nLicense: MIT\n",
+		"values/project1.json": `{"License": "Apache-2.0"}`,
+	}
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+
+	cfg, err := readConfig(filepath.Join(tmpDir, "anvil.yml"), "templates")
+	if err != nil {
+		t.Fatalf("readConfig() error = %v", err)
+	}
+	want := map[string]any{"Author": "Inline", "Year": 2024, "License": "Apache-2.0"}
+	if !reflect.DeepEqual(cfg.Projects[0].Values, want) {
+		t.Errorf("Project values = %v, want %v", cfg.Projects[0].Values, want)
+	}
+
+	if err := os.Remove(filepath.Join(tmpDir, "values/common.yml")); err != nil {
+		t.Fatalf("Failed to remove values file: %v", err)
+	}
+	if _, err := readConfig(filepath.Join(tmpDir, "anvil.yml"), "templates"); err == nil {
+		t.Error("readConfig() expected error for missing values file")
+	}
+}